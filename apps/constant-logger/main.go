@@ -2,11 +2,23 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
+// shutdownLines is how many lines are logged after receiving SIGTERM. The
+// last one has remaining=0.
+const shutdownLines = 5
+
 type VCapApp struct {
 	ApplicationName string `json:"application_name"`
 }
@@ -23,8 +35,244 @@ func main() {
 		log.Fatalf("failed to unmarshal VCAP_APPLICATION")
 	}
 
+	instance := os.Getenv("CF_INSTANCE_INDEX")
+
+	// Every line ends in run=<id>, so the lines of one run of an instance
+	// can be told apart from those of its restarts. The ID is the
+	// container's instance GUID, or the start time where there is none.
+	run := os.Getenv("CF_INSTANCE_GUID")
+	if run == "" {
+		run = strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+
+	// LOG_STREAM selects where lines are written, "stderr" (the default, as
+	// for any use of the log package) or "stdout".
+	switch stream := os.Getenv("LOG_STREAM"); stream {
+	case "", "stderr":
+		log.SetOutput(os.Stderr)
+	case "stdout":
+		log.SetOutput(os.Stdout)
+	default:
+		log.Fatalf("invalid LOG_STREAM: %s", stream)
+	}
+
+	// LOG_LABEL prefixes every line, so a task or sidecar running this app
+	// can be told apart from the web process.
+	label := os.Getenv("LOG_LABEL")
+	if label == "" {
+		label = "APP_LOG"
+	}
+
+	// LOG_COUNT stops logging after that many lines, 0 never stops.
+	// EXIT_AFTER also stops after that many lines, but then logs a final
+	// EXITING line and exits 0. CRASH_AFTER logs a CRASHING line instead
+	// and then exits 1, or panics if CRASH_MODE is "panic".
+	count := uint64(envInt("LOG_COUNT", 0))
+	exitAfter := uint64(envInt("EXIT_AFTER", 0))
+	if exitAfter > 0 {
+		count = exitAfter
+	}
+	crashAfter := uint64(envInt("CRASH_AFTER", 0))
+	if crashAfter > 0 {
+		count = crashAfter
+	}
+	crashMode := os.Getenv("CRASH_MODE")
+
+	// By default one short line is logged every 50ms, or every LOG_INTERVAL.
+	// When BURST_BYTES_PER_SECOND is set, lines are padded to LOG_LINE_BYTES
+	// and logged in batches every 100ms to reach that rate.
+	interval := 50 * time.Millisecond
+	linesPerTick := 1
+	lineBytes := 0
+
+	if v := os.Getenv("LOG_INTERVAL"); v != "" {
+		interval, err = time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			log.Fatalf("invalid LOG_INTERVAL: %s", v)
+		}
+	}
+
+	if burst := envInt("BURST_BYTES_PER_SECOND", 0); burst > 0 {
+		interval = 100 * time.Millisecond
+		lineBytes = envInt("LOG_LINE_BYTES", 256)
+		if lineBytes <= 0 {
+			log.Fatalf("invalid LOG_LINE_BYTES: %d", lineBytes)
+		}
+		linesPerTick = (burst/10 + lineBytes - 1) / lineBytes
+	}
+
+	if port := os.Getenv("PORT"); port != "" {
+		go serve(port, vcapApp.ApplicationName, instance, run)
+	}
+
+	term := make(chan os.Signal, 1)
+	signal.Notify(term, syscall.SIGTERM)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var seq uint64
 	for {
-		log.Printf("APP_LOG: %s", vcapApp.ApplicationName)
-		time.Sleep(50 * time.Millisecond)
+		select {
+		case <-ticker.C:
+			for i := 0; i < linesPerTick && (count == 0 || seq < count); i++ {
+				line := fmt.Sprintf("%s: %s instance=%s seq=%d run=%s", label, vcapApp.ApplicationName, instance, seq, run)
+				log.Print(pad(line, lineBytes))
+				seq++
+			}
+
+			if exitAfter > 0 && seq >= exitAfter {
+				log.Printf("EXITING: %s instance=%s seq=%d run=%s", vcapApp.ApplicationName, instance, seq, run)
+				return
+			}
+
+			if crashAfter > 0 && seq >= crashAfter {
+				crash(crashMode, fmt.Sprintf("CRASHING: %s instance=%s seq=%d run=%s", vcapApp.ApplicationName, instance, seq, run))
+			}
+		case <-term:
+			for remaining := shutdownLines - 1; remaining >= 0; remaining-- {
+				log.Printf("SHUTDOWN: %s instance=%s seq=%d remaining=%d run=%s", vcapApp.ApplicationName, instance, seq, remaining, run)
+				seq++
+			}
+			return
+		}
+	}
+}
+
+// serve listens for commands that change the app's resource usage:
+//
+//	/memory/<MiB>      allocates and holds MiB of memory
+//	/cpu/<duration>    keeps one core busy for duration, e.g. 2m
+//	/crash             exits with status 1
+//	/emit              writes the POSTed body verbatim, plus a newline
+//	/burst/<n>         logs n BURST_LOG lines as fast as possible; with
+//	                   ?id=<prefix> each line has id=<prefix>-<seq>
+func serve(port, appName, instance, run string) {
+	var (
+		mu      sync.Mutex
+		ballast [][]byte
+	)
+
+	http.HandleFunc("/memory/", func(w http.ResponseWriter, r *http.Request) {
+		mib, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/memory/"))
+		if err != nil || mib <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		b := make([]byte, mib<<20)
+		// Touch every page so it counts against the container's memory.
+		for i := 0; i < len(b); i += os.Getpagesize() {
+			b[i] = 1
+		}
+
+		mu.Lock()
+		ballast = append(ballast, b)
+		mu.Unlock()
+
+		fmt.Fprintf(w, "allocated %d MiB\n", mib)
+	})
+
+	http.HandleFunc("/cpu/", func(w http.ResponseWriter, r *http.Request) {
+		d, err := time.ParseDuration(strings.TrimPrefix(r.URL.Path, "/cpu/"))
+		if err != nil || d <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		go func() {
+			deadline := time.Now().Add(d)
+			for time.Now().Before(deadline) {
+			}
+		}()
+
+		fmt.Fprintf(w, "burning cpu for %s\n", d)
+	})
+
+	http.HandleFunc("/crash", func(w http.ResponseWriter, r *http.Request) {
+		crash("exit", fmt.Sprintf("CRASHING: %s instance=%s run=%s", appName, instance, run))
+	})
+
+	http.HandleFunc("/burst/", func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/burst/"))
+		if err != nil || n <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		for seq := 0; seq < n; seq++ {
+			line := fmt.Sprintf("BURST_LOG: %s instance=%s seq=%d", appName, instance, seq)
+			if id != "" {
+				line += fmt.Sprintf(" id=%s-%d", id, seq)
+			}
+			line += " run=" + run
+			log.Print(line)
+		}
+
+		fmt.Fprintf(w, "logged %d lines\n", n)
+	})
+
+	// The body bypasses the logger, so it is written without a timestamp
+	// prefix. Set LOG_COUNT to keep other lines from interleaving with it.
+	http.HandleFunc("/emit", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_, err = log.Writer().Write(append(body, '\n'))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Fprintf(w, "emitted %d bytes\n", len(body))
+	})
+
+	log.Fatal(http.ListenAndServe(":"+port, nil))
+}
+
+// crash logs message to stderr and exits 1, or panics with message if mode
+// is "panic".
+func crash(mode, message string) {
+	if mode == "panic" {
+		panic(message)
+	}
+
+	log.SetOutput(os.Stderr)
+	log.Print(message)
+	os.Exit(1)
+}
+
+// pad appends " pad=xxx..." to line so that it is n bytes long.
+func pad(line string, n int) string {
+	const prefix = " pad="
+
+	missing := n - len(line) - len(prefix)
+	if missing <= 0 {
+		return line
+	}
+
+	return line + prefix + strings.Repeat("x", missing)
+}
+
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
 	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("invalid %s: %s", name, err)
+	}
+
+	return i
 }
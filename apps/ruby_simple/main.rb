@@ -2,6 +2,12 @@ require 'sinatra'
 STDOUT.sync = true
 STDERR.sync = true
 
+# Echo the request ID the router assigned, on every response including
+# errors, so callers can find the RTR log.
+before do
+  headers 'X-Vcap-Request-Id' => request.env['HTTP_X_VCAP_REQUEST_ID'].to_s
+end
+
 get '/' do
 <<-RESPONSE
 Healthy
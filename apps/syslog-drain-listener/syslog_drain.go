@@ -1,14 +1,70 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// priRegex matches the PRI and VERSION that start an RFC 5424 message.
+var priRegex = regexp.MustCompile(`^<(\d{1,3})>1 `)
+
+const (
+	// defaultMaxStoredBytes bounds the messages kept for GET /messages
+	// unless MAX_STORED_BYTES is set.
+	defaultMaxStoredBytes = 16 << 20
+
+	// maxHang is how long a request to /fault/hang is held open.
+	maxHang = 5 * time.Minute
+)
+
+var (
+	store *messageStore
+
+	// printMessages is false when PRINT_MESSAGES is "false", for listeners
+	// that receive more than is worth logging again.
+	printMessages = true
 )
 
 func main() {
+	limit := defaultMaxStoredBytes
+	if v := os.Getenv("MAX_STORED_BYTES"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			log.Fatalf("invalid MAX_STORED_BYTES: %s", v)
+		}
+	}
+
+	// STORE_MATCH keeps only the messages that match it, so that a spec
+	// can drain far more than it reads.
+	var match *regexp.Regexp
+	if v := os.Getenv("STORE_MATCH"); v != "" {
+		var err error
+		match, err = regexp.Compile(v)
+		if err != nil {
+			log.Fatalf("invalid STORE_MATCH: %s", err)
+		}
+	}
+
+	store = &messageStore{
+		limit:   limit,
+		match:   match,
+		evicted: map[string]int{},
+	}
+	printMessages = os.Getenv("PRINT_MESSAGES") != "false"
+
 	http.HandleFunc("/", handleRequest)
+	http.HandleFunc("/messages", handleMessages)
+	http.HandleFunc("/evicted", handleEvicted)
 	http.ListenAndServe(":"+os.Getenv("PORT"), nil)
 }
 
@@ -19,5 +75,185 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer r.Body.Close()
+
+	// Drains to /fault/error are refused, and ones to /fault/hang never get
+	// an answer, so specs can fault one of several drains.
+	switch r.URL.Path {
+	case "/fault/error":
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	case "/fault/hang":
+		select {
+		case <-r.Context().Done():
+		case <-time.After(maxHang):
+		}
+		w.WriteHeader(http.StatusGatewayTimeout)
+		return
+	}
+
+	store.add(r.URL.Path, b)
+
+	if !printMessages {
+		return
+	}
+
+	// Messages are printed as received. Ones without a valid priority are
+	// still printed, but flagged so specs can assert there are none.
+	if !validPriority(b) {
+		fmt.Println("INVALID PRIORITY:", string(b))
+		return
+	}
 	fmt.Println(string(b))
 }
+
+// handleMessages responds with the recent messages that contain the
+// "contains" query parameter, as a JSON array of base64 strings. Unlike the
+// printed messages, these are exactly the bytes that were received. The
+// optional "path" parameter limits them to messages drained to that path,
+// and "limit" to the most recent ones.
+//
+// With a "group" regular expression, the response is instead a JSON object
+// that counts the matching messages by the expression's submatches, joined
+// by spaces. Messages the expression doesn't match are left out.
+func handleMessages(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	contains := query.Get("contains")
+	if r.Method != http.MethodGet || contains == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	var group *regexp.Regexp
+	if v := query.Get("group"); v != "" {
+		var err error
+		group, err = regexp.Compile(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	matched := store.matching(query.Get("path"), []byte(contains))
+	if limit > 0 && len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if group == nil {
+		json.NewEncoder(w).Encode(matched)
+		return
+	}
+
+	counts := map[string]int{}
+	for _, msg := range matched {
+		if m := group.FindSubmatch(msg); m != nil {
+			counts[string(bytes.Join(m[1:], []byte(" ")))]++
+		}
+	}
+	json.NewEncoder(w).Encode(counts)
+}
+
+// handleEvicted responds with how many messages have been evicted from the
+// store to stay within its limit, as a JSON number. The optional "path"
+// parameter limits the count to messages drained to that path.
+func handleEvicted(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(store.evictedFrom(r.URL.Query().Get("path")))
+}
+
+// validPriority reports whether msg starts with a PRI in the range 0-191,
+// i.e. facility 0-23 and severity 0-7.
+func validPriority(msg []byte) bool {
+	m := priRegex.FindSubmatch(msg)
+	if m == nil {
+		return false
+	}
+
+	pri, err := strconv.Atoi(string(m[1]))
+	return err == nil && pri <= 191
+}
+
+// messageStore keeps the most recent messages up to limit bytes, and
+// counts the ones it evicts by path.
+type messageStore struct {
+	mu       sync.Mutex
+	messages []message
+	size     int
+	limit    int
+	match    *regexp.Regexp
+	evicted  map[string]int
+}
+
+// message is a drained message and the path it was drained to.
+type message struct {
+	path string
+	body []byte
+}
+
+func (s *messageStore) add(path string, body []byte) {
+	if s.match != nil && !s.match.Match(body) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, message{path: path, body: body})
+	s.size += len(body)
+
+	for s.size > s.limit && len(s.messages) > 0 {
+		s.size -= len(s.messages[0].body)
+		s.evicted[s.messages[0].path]++
+		s.messages[0] = message{}
+		s.messages = s.messages[1:]
+	}
+}
+
+// evictedFrom returns how many messages drained to path were evicted, from
+// any path if path is empty.
+func (s *messageStore) evictedFrom(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if path != "" {
+		return s.evicted[path]
+	}
+
+	total := 0
+	for _, n := range s.evicted {
+		total += n
+	}
+	return total
+}
+
+// matching returns the bodies that contain substr, from any path if path
+// is empty.
+func (s *messageStore) matching(path string, substr []byte) [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matched := [][]byte{}
+	for _, msg := range s.messages {
+		if (path == "" || msg.path == path) && bytes.Contains(msg.body, substr) {
+			matched = append(matched, msg.body)
+		}
+	}
+
+	return matched
+}
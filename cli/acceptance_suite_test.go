@@ -2,6 +2,7 @@ package cli_test
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"testing"
 
@@ -69,9 +70,21 @@ var _ = BeforeSuite(func() {
 	logWriterAppName2 = helpers.PushLogWriter()
 })
 
+var _ = AfterEach(func() {
+	helpers.ReportCapturedOutput()
+})
+
 var _ = AfterSuite(func() {
 	cfg := cli.Config()
 
+	// Registered first so the binary is removed even if deleting the org
+	// fails.
+	defer helpers.CleanupSpaceDrain()
+
+	if summary := helpers.DuplicateSummary(); summary != "" {
+		fmt.Println(summary)
+	}
+
 	deleteOrg(cfg)
 })
 
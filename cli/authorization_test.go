@@ -0,0 +1,161 @@
+package cli_test
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Authorization", func() {
+
+	// window is how long denied streams are watched for envelopes.
+	const window = 30 * time.Second
+
+	const guidPattern = `[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`
+	guidRegex := regexp.MustCompile(guidPattern)
+
+	var (
+		userSpace string
+		ownApp    string
+		ownGUID   string
+		otherApp  string
+		otherGUID string
+		user      *User
+		streams   []*LineStream
+	)
+
+	BeforeEach(func() {
+		streams = nil
+		user = nil
+
+		// The users get roles in a space of their own. The suite's space,
+		// with otherApp, is the space they may not read.
+		otherApp = PushConstantLogger(nil)
+		otherGUID = AppGUID(otherApp)
+
+		userSpace = generator.PrefixedRandomName(TestPrefix, "user-space")
+		CF("create-space", userSpace, "-o", org)
+		CF("target", "-o", org, "-s", userSpace)
+		defer CF("target", "-o", org, "-s", space)
+
+		ownApp = PushConstantLogger(nil)
+		ownGUID = AppGUID(ownApp)
+	})
+
+	AfterEach(func() {
+		for _, s := range streams {
+			s.Kill()
+		}
+
+		if user != nil {
+			user.Delete()
+		}
+
+		cf.Cf("delete", otherApp, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		cf.Cf("delete-space", userSpace, "-o", org, "-f").Wait(cli.Config().AppPushTimeout)
+	})
+
+	// streamFor starts a log-stream of sourceID as the user.
+	streamFor := func(sourceID string) *LineStream {
+		s := user.LogStreamLines(sourceID)
+		streams = append(streams, s)
+		return s
+	}
+
+	// envelopesFrom returns a matcher for envelopes from sourceID.
+	envelopesFrom := func(sourceID string) func(string) bool {
+		return func(line string) bool {
+			e, ok := ParseEnvelope(line)
+			return ok && e.SourceID == sourceID
+		}
+	}
+
+	DescribeTable("limits what a space user can read",
+		func(role string) {
+			cfg := cli.Config()
+
+			user = CreateUser()
+			CF("set-space-role", user.Name, org, userSpace, role)
+			user.Target(org, userSpace)
+
+			// The platform streams are checked as the admin first, so that
+			// seeing nothing as the user means the user was denied.
+			for _, sourceID := range []string{"uaa", "doppler"} {
+				s := LogStreamLines(sourceID)
+				streams = append(streams, s)
+
+				_, err := s.WaitFor(envelopesFrom(sourceID), cfg.DefaultTimeout)
+				Expect(err).ToNot(HaveOccurred(), "admin log-stream of %s", sourceID)
+			}
+
+			own := streamFor(ownGUID)
+			other := streamFor(otherGUID)
+			uaa := streamFor("uaa")
+			doppler := streamFor("doppler")
+
+			_, err := own.WaitFor(envelopesFrom(ownGUID), cfg.DefaultTimeout)
+			Expect(err).ToNot(HaveOccurred(), "log-stream of an app in the user's space")
+
+			// A stream that is merely slow shows no envelopes either, so
+			// each source must also be denied when read directly.
+			for _, sourceID := range []string{otherGUID, "uaa", "doppler"} {
+				status, body := user.LogCacheRead(sourceID)
+				Expect(status).To(Or(Equal(http.StatusForbidden), Equal(http.StatusNotFound)), "Log Cache read of %s: %s", sourceID, body)
+				Expect(string(body)).ToNot(ContainSubstring(otherGUID), "Log Cache read of %s leaked a GUID", sourceID)
+			}
+
+			time.Sleep(window)
+
+			for sourceID, s := range map[string]*LineStream{
+				otherGUID: other,
+				"uaa":     uaa,
+				"doppler": doppler,
+			} {
+				for _, line := range s.Recent() {
+					Expect(envelopesFrom(sourceID)(line)).To(BeFalse(), "log-stream of %s", sourceID)
+
+					// Errors may echo the source ID asked for, but no other.
+					for _, guid := range guidRegex.FindAllString(line, -1) {
+						Expect(guid).To(Equal(sourceID), "log-stream of %s leaked a GUID", sourceID)
+					}
+				}
+			}
+
+			ownRecent := user.LogsTail(ownApp).Wait(cfg.DefaultTimeout)
+			Expect(ownRecent).To(Exit(0))
+			Expect(string(ownRecent.Out.Contents())).To(ContainSubstring("APP_LOG: " + ownApp + " "))
+
+			for _, args := range [][]string{
+				{"logs", otherApp, "--recent"},
+				{"logs", otherApp},
+			} {
+				s := user.Cf(args...).Wait(cfg.DefaultTimeout)
+				Expect(s).To(Exit(1), "cf %v", args)
+
+				output := string(s.Out.Contents()) + string(s.Err.Contents())
+				Expect(output).To(ContainSubstring("App '%s' not found", otherApp), "cf %v", args)
+				Expect(output).ToNot(MatchRegexp(guidPattern), "cf %v leaked a GUID", args)
+			}
+
+			// The app is not merely outside the user's target: the user may
+			// not target its space at all.
+			s := user.Cf("target", "-o", org, "-s", space).Wait(cfg.DefaultTimeout)
+			Expect(s).ToNot(Exit(0), "user targeted the suite's space")
+
+			ownStatus, ownBody := user.LogCacheRead(ownGUID)
+			Expect(ownStatus).To(Equal(http.StatusOK), "Log Cache read of %s: %s", ownGUID, ownBody)
+		},
+		Entry("as a SpaceDeveloper", "SpaceDeveloper"),
+		Entry("as a SpaceAuditor", "SpaceAuditor"),
+	)
+})
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"fmt"
 	"log"
 	"time"
 
@@ -14,21 +15,62 @@ type TestConfig struct {
 
 	SkipCertVerify bool `env:"SKIP_SSL_VALIDATION"`
 
-	DefaultTimeout time.Duration `env:"DEFAULT_TIMEOUT"`
-	AppPushTimeout time.Duration `env:"APP_PUSH_TIMEOUT"`
+	DefaultTimeout        time.Duration `env:"DEFAULT_TIMEOUT"`
+	AppPushTimeout        time.Duration `env:"APP_PUSH_TIMEOUT"`
+	DrainReconcileTimeout time.Duration `env:"DRAIN_RECONCILE_TIMEOUT"`
+
+	// EnvelopeTypeTimeout covers the platform's slowest emitters, which
+	// report once a minute or less.
+	EnvelopeTypeTimeout time.Duration `env:"ENVELOPE_TYPE_TIMEOUT"`
+
+	MaxLogLineBytes int `env:"MAX_LOG_LINE_BYTES"`
+
+	MaxOutOfOrder         int    `env:"MAX_OUT_OF_ORDER"`
+	MaxOutOfOrderDistance uint64 `env:"MAX_OUT_OF_ORDER_DISTANCE"`
+
+	// MaxDuplicateRate of 0 only reports duplicates.
+	MaxDuplicateRate float64 `env:"MAX_DUPLICATE_RATE"`
+
+	FanOutDrains int `env:"FAN_OUT_DRAINS"`
+
+	// DrainSwitchMaxGap is in lines, which the drain update specs log
+	// every 100ms.
+	DrainSwitchMaxGap int `env:"DRAIN_SWITCH_MAX_GAP"`
+
+	NoisyNeighborMinDelivery       float64 `env:"NOISY_NEIGHBOR_MIN_DELIVERY"`
+	NoisyNeighborPlacementRestarts int     `env:"NOISY_NEIGHBOR_PLACEMENT_RESTARTS"`
+
+	// FanInApps of 0 skips the fan-in scale specs.
+	FanInApps            int           `env:"FAN_IN_APPS"`
+	FanInPushConcurrency int           `env:"FAN_IN_PUSH_CONCURRENCY"`
+	FanInPushInterval    time.Duration `env:"FAN_IN_PUSH_INTERVAL"`
 }
 
 var config *TestConfig
 
 func LoadConfig() (*TestConfig, error) {
 	config := &TestConfig{
-		DefaultTimeout: 90 * time.Second,
-		AppPushTimeout: 180 * time.Second,
+		DefaultTimeout:                 90 * time.Second,
+		AppPushTimeout:                 180 * time.Second,
+		DrainReconcileTimeout:          5 * time.Minute,
+		EnvelopeTypeTimeout:            5 * time.Minute,
+		MaxLogLineBytes:                61440,
+		FanOutDrains:                   5,
+		DrainSwitchMaxGap:              600,
+		NoisyNeighborMinDelivery:       0.99,
+		NoisyNeighborPlacementRestarts: 10,
+		FanInPushConcurrency:           4,
+		FanInPushInterval:              2 * time.Second,
 	}
 	err := envstruct.Load(config)
 	if err != nil {
 		return nil, err
 	}
+
+	// The fan-out specs compare drains, one of which may be faulted.
+	if config.FanOutDrains < 2 {
+		return nil, fmt.Errorf("FAN_OUT_DRAINS must be at least 2, got %d", config.FanOutDrains)
+	}
 	return config, nil
 }
 
@@ -0,0 +1,113 @@
+package cli_test
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ContainerMetrics", func() {
+
+	const (
+		instances    = 2
+		allocatedMiB = 24
+
+		// minCPU is a low bar for an instance spinning one core, since the
+		// percentage depends on the cell's CPU entitlement.
+		minCPU = 25.0
+	)
+
+	var (
+		appName string
+		appGUID string
+		streams []*LineStream
+	)
+
+	// plausible checks a container metrics envelope from an instance that
+	// was told to allocate memory and burn CPU.
+	plausible := func(e Envelope) bool {
+		if e.SourceID != appGUID || e.Gauge == nil {
+			return false
+		}
+
+		m := e.Gauge.Metrics
+		cpu, memory, disk := m["cpu"], m["memory"], m["disk"]
+		memoryQuota, diskQuota := m["memory_quota"], m["disk_quota"]
+
+		return cpu.Unit == "percentage" && cpu.Value >= minCPU &&
+			memory.Unit == "bytes" && memory.Value >= allocatedMiB<<20 &&
+			(memoryQuota.Value == 0 || memory.Value <= memoryQuota.Value) &&
+			disk.Unit == "bytes" && disk.Value > 0 &&
+			(diskQuota.Value == 0 || disk.Value <= diskQuota.Value)
+	}
+
+	BeforeEach(func() {
+		streams = nil
+
+		appName = PushConstantLogger(nil, "-i", strconv.Itoa(instances))
+		appGUID = AppGUID(appName)
+
+		Eventually(func() int {
+			return RunningInstances(appName)
+		}, cli.Config().AppPushTimeout, 2*time.Second).Should(Equal(instances))
+	})
+
+	AfterEach(func() {
+		for _, s := range streams {
+			s.Kill()
+		}
+
+		cf.Cf("delete", appName, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+	})
+
+	loadInstances := func() {
+		for i := 0; i < instances; i++ {
+			CommandConstantLogger(appName, i, fmt.Sprintf("/memory/%d", allocatedMiB))
+			CommandConstantLogger(appName, i, "/cpu/5m")
+		}
+	}
+
+	It("streams plausible container metrics for every instance", func() {
+		logs := LogStreamLines(appName)
+		streams = append(streams, logs)
+
+		loadInstances()
+
+		for i := 0; i < instances; i++ {
+			instance := strconv.Itoa(i)
+
+			_, err := logs.WaitFor(func(line string) bool {
+				e, ok := ParseEnvelope(line)
+				return ok && e.InstanceID == instance && plausible(e)
+			}, cli.Config().DefaultTimeout+3*time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+
+	It("stores plausible container metrics for every instance in Log Cache", func() {
+		loadInstances()
+
+		want := map[string]bool{}
+		for i := 0; i < instances; i++ {
+			want[strconv.Itoa(i)] = true
+		}
+
+		Eventually(func() map[string]bool {
+			seen := map[string]bool{}
+			for _, e := range LogCacheReadAll(appGUID, url.Values{"envelope_types": {"GAUGE"}}) {
+				if plausible(e) {
+					seen[e.InstanceID] = true
+				}
+			}
+			return seen
+		}, cli.Config().DefaultTimeout+3*time.Minute, 10*time.Second).Should(Equal(want))
+	})
+})
@@ -0,0 +1,162 @@
+package cli_test
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+// cellStoppingRegex matches the cell stopping an instance, by instance GUID,
+// in cf logs and at the drain.
+var cellStoppingRegex = regexp.MustCompile(`\[CELL/0\].*[Ss]topping instance (\S+)`)
+
+var _ = Describe("CrashLogs", func() {
+
+	const crashAfter = 200
+
+	var (
+		appName   string
+		drainName string
+		drainLogs *LineStream
+	)
+
+	BeforeEach(func() {
+		appName = ""
+		drainName = ""
+		drainLogs = nil
+	})
+
+	AfterEach(func() {
+		if drainLogs != nil {
+			drainLogs.Kill()
+		}
+
+		if drainName != "" {
+			cf.Cf("delete-drain", drainName, "--force").Wait(cli.Config().DefaultTimeout)
+		}
+
+		if appName != "" {
+			cf.Cf("delete", appName, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		}
+	})
+
+	pushCrashingApp := func(mode string) {
+		appName = PushConstantLogger(map[string]string{
+			"CRASH_AFTER": strconv.Itoa(crashAfter),
+			"CRASH_MODE":  mode,
+		})
+	}
+
+	// completeRun returns the ID of a run of the app that has every line
+	// before its crash, its CRASHING line, matched by crashing, and the
+	// cell stopping its instance in lines. The app is restarted after every
+	// crash and each run logs the same sequence numbers, so lines are only
+	// counted within their run.
+	completeRun := func(lines []string, crashing *regexp.Regexp) (string, bool) {
+		var (
+			seen    = map[string]map[uint64]bool{}
+			crashed = map[string]bool{}
+			stopped = map[string]bool{}
+		)
+
+		for _, line := range lines {
+			if m := cellStoppingRegex.FindStringSubmatch(line); m != nil {
+				stopped[m[1]] = true
+				continue
+			}
+
+			run, ok := ParseRun(line)
+			if !ok {
+				continue
+			}
+
+			if seq, ok := ParseSequence(line, "APP_LOG", appName); ok {
+				if seen[run] == nil {
+					seen[run] = map[uint64]bool{}
+				}
+				seen[run][seq] = true
+			}
+
+			if crashing.MatchString(line) {
+				crashed[run] = true
+			}
+		}
+
+		for run, seqs := range seen {
+			if crashed[run] && stopped[run] && len(MissingSequences(seqs, crashAfter)) == 0 {
+				return run, true
+			}
+		}
+
+		return "", false
+	}
+
+	DescribeTable("keeps every line logged before a crash in cf logs --recent",
+		func(mode string) {
+			pushCrashingApp(mode)
+
+			crashing := regexp.MustCompile(fmt.Sprintf(`\[APP/PROC/WEB/0\]\s+ERR .*CRASHING: %s instance=0 seq=%d run=`, appName, crashAfter))
+
+			Eventually(func() bool {
+				s := LogsTail(appName).Wait(cli.Config().DefaultTimeout)
+
+				_, ok := completeRun(strings.Split(string(s.Out.Contents()), "\n"), crashing)
+				return ok
+			}, cli.Config().DefaultTimeout+3*time.Minute, 5*time.Second).Should(BeTrue(), "no run with every line, its crash and its stop")
+		},
+		Entry("when the app exits", "exit"),
+		Entry("when the app panics", "panic"),
+	)
+
+	DescribeTable("keeps every line logged before a crash at the drain",
+		func(mode string) {
+			drainLogs = LogsFollowLines(listenerAppName)
+
+			pushCrashingApp(mode)
+
+			// Other apps' cell logs reach the listener too, but only this
+			// app's runs have lines to match them with.
+			var (
+				mu    sync.Mutex
+				lines []string
+			)
+			drainLogs.OnLine(func(line string) {
+				if strings.Contains(line, appName) || strings.Contains(line, "[CELL/0]") {
+					mu.Lock()
+					lines = append(lines, line)
+					mu.Unlock()
+				}
+			})
+
+			// The drain is bound after the push, so the first run may have
+			// lost lines before it was bound. Later runs must be complete.
+			syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
+			drainName = generator.PrefixedRandomName("CRASH-LOGS", "DRAIN")
+			CF("drain", appName, syslogDrainURL, "--drain-name", drainName)
+
+			crashing := regexp.MustCompile(fmt.Sprintf(`\[APP/PROC/WEB/0\] - .*CRASHING: %s instance=0 seq=%d run=`, appName, crashAfter))
+
+			Eventually(func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+
+				_, ok := completeRun(lines, crashing)
+				return ok
+			}, cli.Config().DefaultTimeout+3*time.Minute, 5*time.Second).Should(BeTrue(), "no run with every line, its crash and its stop")
+		},
+		Entry("when the app exits", "exit"),
+		Entry("when the app panics", "panic"),
+	)
+})
@@ -0,0 +1,160 @@
+package cli_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Crosstalk", func() {
+
+	// window is how long messages from both orgs flow before the
+	// streams are checked.
+	const window = 30 * time.Second
+
+	var (
+		orgB      string
+		spaceB    string
+		homeB     *Home
+		appA      string
+		appB      string
+		listenerB string
+		drainA    string
+		pathA     string
+		user      *User
+		streams   []*LineStream
+	)
+
+	drainURL := func(listener, path string) string {
+		return fmt.Sprintf("https://%s.%s%s", listener, cli.Config().CFDomain, path)
+	}
+
+	BeforeEach(func() {
+		streams = nil
+		homeB = nil
+		drainA = ""
+		user = nil
+
+		orgB = generator.PrefixedRandomName(TestPrefix, "org-b")
+		spaceB = generator.PrefixedRandomName(TestPrefix, "space-b")
+		CF("create-org", orgB)
+		CF("create-space", spaceB, "-o", orgB)
+
+		// Org A is the suite's org, draining to the suite's listener.
+		appA = PushConstantLogger(nil)
+		pathA = "/" + strings.ToLower(generator.PrefixedRandomName("crosstalk", "a"))
+		drainA = generator.PrefixedRandomName("CROSSTALK", "DRAIN")
+		CF("drain", appA, drainURL(listenerAppName, pathA), "--drain-name", drainA)
+
+		// Org B is targeted in a home of its own, so the suite's target
+		// never changes.
+		homeB = NewHome(orgB, spaceB)
+		listenerB = homeB.PushSyslogServer()
+		appB = homeB.PushConstantLogger(nil)
+		homeB.CF("drain", appB, drainURL(listenerB, "/"), "--drain-name", generator.PrefixedRandomName("CROSSTALK", "DRAIN"))
+
+		user = CreateUser()
+		CF("set-space-role", user.Name, orgB, spaceB, "SpaceDeveloper")
+		user.Target(orgB, spaceB)
+	})
+
+	AfterEach(func() {
+		for _, s := range streams {
+			s.Kill()
+		}
+
+		if user != nil {
+			user.Delete()
+		}
+		if homeB != nil {
+			homeB.Remove()
+		}
+
+		if drainA != "" {
+			cf.Cf("delete-drain", drainA, "--force").Wait(cli.Config().DefaultTimeout)
+		}
+		cf.Cf("delete", appA, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		cf.Cf("delete-org", orgB, "-f").Wait(cli.Config().AppPushTimeout)
+	})
+
+	It("keeps org A's logs out of org B's drain, cf logs and log-stream", func() {
+		cfg := cli.Config()
+		markerA := "APP_LOG: " + appA + " "
+		markerB := "APP_LOG: " + appB + " "
+
+		// Every line is checked as it arrives, since the streams only keep
+		// the most recent ones.
+		var (
+			mu     sync.Mutex
+			leaked = map[string][]string{}
+		)
+		watch := func(name string, s *LineStream) *LineStream {
+			streams = append(streams, s)
+			s.OnLine(func(line string) {
+				if strings.Contains(line, markerA) {
+					mu.Lock()
+					leaked[name] = append(leaked[name], line)
+					mu.Unlock()
+				}
+			})
+			return s
+		}
+
+		adminLogsB := watch("admin cf logs", homeB.LogsFollowLines(appB))
+		userLogsB := watch("user cf logs", user.LogsFollowLines(appB))
+		userStreamB := watch("user log-stream", user.LogStreamLines(appB))
+		// The user may not read org A, so this stream must stay empty of
+		// org A's logs even though it names org A's app.
+		guidA := AppGUID(appA)
+		watch("user log-stream of org A", user.LogStreamLines(guidA))
+
+		// Each path must carry org B's own logs, and org A's drain its
+		// logs, or the absence of org A's logs would prove nothing.
+		Eventually(func() bool {
+			return HasDrainedMessageAt(listenerAppName, pathA, markerA)
+		}, cfg.DrainReconcileTimeout, 5*time.Second).Should(BeTrue(), "org A's drain")
+		Eventually(func() bool {
+			return HasDrainedMessageAt(listenerB, "/", markerB)
+		}, cfg.DrainReconcileTimeout, 5*time.Second).Should(BeTrue(), "org B's drain")
+
+		for name, s := range map[string]*LineStream{
+			"admin cf logs":   adminLogsB,
+			"user cf logs":    userLogsB,
+			"user log-stream": userStreamB,
+		} {
+			_, err := s.WaitFor(Containing(markerB), cfg.DefaultTimeout)
+			Expect(err).ToNot(HaveOccurred(), name)
+		}
+
+		// An empty stream proves nothing unless the user was denied.
+		status, body := user.LogCacheRead(guidA)
+		Expect(status).To(Or(Equal(http.StatusForbidden), Equal(http.StatusNotFound)), "user Log Cache read of org A: %s", body)
+
+		time.Sleep(window)
+
+		Expect(DrainedMessages(listenerB, markerA)).To(BeEmpty(), "org B's drain")
+		Expect(DrainedMessagesAt(listenerAppName, pathA, markerB)).To(BeEmpty(), "org A's drain")
+
+		recent := string(homeB.LogsTail(appB).Wait(cfg.DefaultTimeout).Out.Contents())
+		Expect(recent).To(ContainSubstring(markerB), "admin cf logs --recent")
+		Expect(recent).ToNot(ContainSubstring(markerA), "admin cf logs --recent")
+
+		s := user.LogsTail(appB).Wait(cfg.DefaultTimeout)
+		Expect(string(s.Out.Contents())).To(ContainSubstring(markerB), "user cf logs --recent")
+		Expect(string(s.Out.Contents())).ToNot(ContainSubstring(markerA), "user cf logs --recent")
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(leaked).To(BeEmpty(), "org A's lines in org B's streams")
+	})
+})
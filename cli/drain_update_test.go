@@ -0,0 +1,208 @@
+package cli_test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DrainUpdate", func() {
+
+	const (
+		// logInterval makes every lost or repeated line stand for 100ms.
+		logInterval = 100 * time.Millisecond
+
+		pollInterval = 2 * time.Second
+
+		// quietPeriod without new lines means a drain has stopped.
+		quietPeriod = 20 * time.Second
+
+		rebindCycles = 3
+	)
+
+	var (
+		appName   string
+		drainName string
+		pathA     string
+		pathB     string
+	)
+
+	drainURL := func(path string) string {
+		return fmt.Sprintf("https://%s.%s%s", listenerAppName, cli.Config().CFDomain, path)
+	}
+
+	// drained counts how often each of the app's lines reached path.
+	drained := func(path string) map[uint64]int {
+		counts := map[uint64]int{}
+		for _, msg := range DrainedMessagesAt(listenerAppName, path, "APP_LOG: "+appName+" ") {
+			if seq, ok := ParseSequence(string(msg), "APP_LOG", appName); ok {
+				counts[seq]++
+			}
+		}
+		return counts
+	}
+
+	maxSeq := func(counts map[uint64]int) uint64 {
+		var max uint64
+		for seq := range counts {
+			if seq > max {
+				max = seq
+			}
+		}
+		return max
+	}
+
+	// expectFewDuplicates records the deliveries in counts and holds their
+	// repeats to the rate allowed for any other drain. Only the lines that
+	// arrived count as emitted, since those logged while a drain is unbound
+	// or moving never could.
+	expectFewDuplicates := func(name string, counts map[uint64]int) {
+		var ids []string
+		for seq, n := range counts {
+			for i := 0; i < n; i++ {
+				ids = append(ids, strconv.FormatUint(seq, 10))
+			}
+		}
+
+		r := CountDuplicates(name, len(counts), ids)
+		RecordDuplicates(r)
+		fmt.Fprintln(GinkgoWriter, r)
+
+		if maxRate := cli.Config().MaxDuplicateRate; maxRate > 0 {
+			ExpectWithOffset(1, r.Rate()).To(BeNumerically("<=", maxRate), r.String())
+		}
+	}
+
+	// waitForStart returns how long after since path first has a line
+	// after seq. It fails if that takes longer than DrainReconcileTimeout,
+	// as does waitForStop.
+	waitForStart := func(path string, seq uint64, since time.Time) time.Duration {
+		var started time.Duration
+		Eventually(func() bool {
+			counts := drained(path)
+			if len(counts) > 0 && maxSeq(counts) > seq {
+				started = time.Since(since)
+				return true
+			}
+			return false
+		}, cli.Config().DrainReconcileTimeout, pollInterval).Should(BeTrue(), "%s never started receiving", path)
+
+		return started
+	}
+
+	// waitForStop returns how long after since path last got a new line,
+	// once it has had none for quietPeriod.
+	waitForStop := func(path string, since time.Time) time.Duration {
+		last := maxSeq(drained(path))
+		lastGrowth := time.Now()
+
+		Eventually(func() bool {
+			if seq := maxSeq(drained(path)); seq > last {
+				last, lastGrowth = seq, time.Now()
+			}
+			return time.Since(lastGrowth) >= quietPeriod
+		}, cli.Config().DrainReconcileTimeout+quietPeriod, pollInterval).Should(BeTrue(), "%s never stopped receiving", path)
+
+		return lastGrowth.Sub(since)
+	}
+
+	BeforeEach(func() {
+		prefix := strings.ToLower(generator.PrefixedRandomName("drain-update", ""))
+		pathA = "/" + prefix + "-a"
+		pathB = "/" + prefix + "-b"
+
+		appName = PushConstantLogger(map[string]string{"LOG_INTERVAL": logInterval.String()})
+
+		drainName = generator.PrefixedRandomName("DRAIN-UPDATE", "DRAIN")
+		CF("drain", appName, drainURL(pathA), "--drain-name", drainName)
+
+		waitForStart(pathA, 0, time.Now())
+	})
+
+	AfterEach(func() {
+		cf.Cf("delete-drain", drainName, "--force").Wait(cli.Config().DefaultTimeout)
+		cf.Cf("delete", appName, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+	})
+
+	It("moves logs to the new URL when the drain's URL is updated", func() {
+		switchSeq := maxSeq(drained(pathA))
+		switched := time.Now()
+
+		CF("update-user-provided-service", drainName, "-l", drainURL(pathB))
+
+		started := waitForStart(pathB, 0, switched)
+		stopped := waitForStop(pathA, switched)
+
+		// Lines are compared from the switch up to the last one B has.
+		a, b := drained(pathA), drained(pathB)
+		hi := maxSeq(b)
+
+		var lost, doubled, repeated int
+		atA, atB := map[uint64]int{}, map[uint64]int{}
+		for seq := switchSeq + 1; seq <= hi; seq++ {
+			if a[seq] > 0 {
+				atA[seq] = a[seq]
+			}
+			if b[seq] > 0 {
+				atB[seq] = b[seq]
+			}
+
+			switch {
+			case a[seq] == 0 && b[seq] == 0:
+				lost++
+			case a[seq] > 0 && b[seq] > 0:
+				doubled++
+			}
+			if a[seq] > 1 || b[seq] > 1 {
+				repeated++
+			}
+		}
+
+		fmt.Fprintf(
+			GinkgoWriter,
+			"switch: B started after %s, A stopped after %s; of seq %d-%d %d lost, %d at both, %d repeated at one\n",
+			started, stopped, switchSeq+1, hi, lost, doubled, repeated,
+		)
+
+		Expect(hi).To(BeNumerically(">", switchSeq), "no lines after the switch")
+
+		maxGap := cli.Config().DrainSwitchMaxGap
+		Expect(lost).To(BeNumerically("<=", maxGap), "lines lost during the switch")
+		Expect(doubled).To(BeNumerically("<=", maxGap), "lines delivered to both URLs during the switch")
+
+		expectFewDuplicates("drain update A", atA)
+		expectFewDuplicates("drain update B", atB)
+	})
+
+	It("stops and resumes draining across unbind and rebind cycles", func() {
+		for cycle := 1; cycle <= rebindCycles; cycle++ {
+			unbound := time.Now()
+			CF("unbind-service", appName, drainName)
+			stopped := waitForStop(pathA, unbound)
+
+			stopSeq := maxSeq(drained(pathA))
+			rebound := time.Now()
+			CF("bind-service", appName, drainName)
+			resumed := waitForStart(pathA, stopSeq, rebound)
+
+			fmt.Fprintf(
+				GinkgoWriter,
+				"cycle %d: stopped %s after unbind, resumed %s after rebind\n",
+				cycle, stopped, resumed,
+			)
+
+		}
+
+		// Lines missed while unbound are expected.
+		expectFewDuplicates("drain rebind", drained(pathA))
+	})
+})
@@ -0,0 +1,146 @@
+package cli_test
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Duplicates", func() {
+
+	const burstLines = 2000
+
+	var (
+		appName   string
+		appGUID   string
+		drainName string
+		appLogs   *LineStream
+		logStream *LineStream
+	)
+
+	BeforeEach(func() {
+		// LOG_COUNT keeps the app's own lines out of the burst.
+		appName = PushConstantLogger(map[string]string{"LOG_COUNT": "1"})
+		appGUID = AppGUID(appName)
+
+		syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
+		drainName = generator.PrefixedRandomName("DUPLICATES", "DRAIN")
+		CF("drain", appName, syslogDrainURL, "--drain-name", drainName)
+		AwaitDrainDelivery(appName, listenerAppName)
+
+		appLogs = LogsFollowLines(appName)
+		logStream = LogStreamLines(appName)
+	})
+
+	AfterEach(func() {
+		appLogs.Kill()
+		logStream.Kill()
+
+		cf.Cf("delete-drain", drainName, "--force").Wait(cli.Config().DefaultTimeout)
+		cf.Cf("delete", appName, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+	})
+
+	It("reports duplicate deliveries on every egress path", func() {
+		prefix := generator.PrefixedRandomName("DUP", "")
+
+		// ids collects the IDs delivered on each path, counting repeats.
+		var (
+			mu  sync.Mutex
+			ids = map[string][]string{}
+		)
+		add := func(path, line string) {
+			id, ok := ParseMessageID(line)
+			if !ok || !strings.HasPrefix(id, prefix+"-") {
+				return
+			}
+
+			mu.Lock()
+			ids[path] = append(ids[path], id)
+			mu.Unlock()
+		}
+
+		appLogs.OnLine(func(line string) {
+			add("cf logs", line)
+		})
+		logStream.OnLine(func(line string) {
+			if e, ok := ParseEnvelope(line); ok && e.Log != nil {
+				add("log-stream", string(e.Log.Payload))
+			}
+		})
+
+		CommandConstantLogger(appName, 0, fmt.Sprintf("/burst/%d?id=%s", burstLines, prefix))
+
+		// The drain and Log Cache are read in full on every poll, so their
+		// IDs are replaced rather than added to.
+		poll := func() {
+			var drained, cached []string
+			for _, msg := range DrainedMessages(listenerAppName, "id="+prefix+"-") {
+				if id, ok := ParseMessageID(string(msg)); ok {
+					drained = append(drained, id)
+				}
+			}
+			for _, e := range LogCacheReadAll(appGUID, url.Values{"envelope_types": {"LOG"}}) {
+				if id, ok := ParseMessageID(string(e.Log.Payload)); ok && strings.HasPrefix(id, prefix+"-") {
+					cached = append(cached, id)
+				}
+			}
+
+			mu.Lock()
+			ids["drain"] = drained
+			ids["Log Cache"] = cached
+			mu.Unlock()
+		}
+
+		paths := []string{"drain", "cf logs", "log-stream", "Log Cache"}
+
+		// Lost lines are not what this spec checks, so it waits until every
+		// path has every line or the timeout expires.
+		deadline := time.Now().Add(cli.Config().DefaultTimeout)
+		for {
+			poll()
+
+			complete := true
+			mu.Lock()
+			for _, path := range paths {
+				if CountDuplicates(path, burstLines, ids[path]).Unique < burstLines {
+					complete = false
+				}
+			}
+			mu.Unlock()
+
+			if complete || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(5 * time.Second)
+		}
+
+		// Late duplicates may still arrive on the streams.
+		time.Sleep(5 * time.Second)
+		poll()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		maxRate := cli.Config().MaxDuplicateRate
+		for _, path := range paths {
+			r := CountDuplicates(path, burstLines, ids[path])
+			RecordDuplicates(r)
+			fmt.Fprintln(GinkgoWriter, r)
+
+			Expect(r.Unique).ToNot(BeZero(), "%s: nothing delivered", path)
+			if maxRate > 0 {
+				Expect(r.Rate()).To(BeNumerically("<=", maxRate), r.String())
+			}
+		}
+	})
+})
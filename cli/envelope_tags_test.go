@@ -0,0 +1,111 @@
+package cli_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EnvelopeTags", func() {
+
+	var (
+		appName   string
+		appGUID   string
+		drainName string
+	)
+
+	const guidPattern = `^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`
+
+	BeforeEach(func() {
+		appName = PushConstantLogger(nil)
+		appGUID = AppGUID(appName)
+
+		syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
+		drainName = generator.PrefixedRandomName("ENVELOPE-TAGS", "DRAIN")
+		CF("drain", appName, syslogDrainURL, "--drain-name", drainName)
+	})
+
+	AfterEach(func() {
+		cf.Cf("delete-drain", drainName, "--force").Wait(cli.Config().DefaultTimeout)
+		cf.Cf("delete", appName, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+	})
+
+	// expectTags checks the tags of an app log envelope in log-stream and
+	// of a drained message, both logged after the call and tagged with the
+	// given app name.
+	expectTags := func(name string) {
+		timeout := cli.Config().DefaultTimeout + 3*time.Minute
+		marker := "APP_LOG: " + name + " "
+
+		// Both streams only see lines that arrive from now on.
+		logStream := LogStreamLines(appGUID)
+		defer logStream.Kill()
+		drainLogs := LogsFollowLines(listenerAppName)
+		defer drainLogs.Kill()
+
+		line, err := logStream.WaitFor(func(line string) bool {
+			e, ok := ParseEnvelope(line)
+			return ok && e.Log != nil && strings.Contains(string(e.Log.Payload), marker)
+		}, timeout)
+		Expect(err).ToNot(HaveOccurred(), "log-stream")
+
+		e, _ := ParseEnvelope(line)
+		Expect(e.SourceID).To(Equal(appGUID))
+		Expect(e.InstanceID).To(Equal("0"))
+		Expect(e.Tags["source_type"]).To(Equal("APP/PROC/WEB"))
+		Expect(e.Tags["app_name"]).To(Equal(name))
+		Expect(e.Tags["space_name"]).To(Equal(space))
+		Expect(e.Tags["organization_name"]).To(Equal(org))
+		Expect(e.Tags["process_type"]).To(Equal("web"))
+		Expect(e.Tags["instance_id"]).To(Equal("0"))
+		Expect(e.Tags["process_instance_id"]).To(MatchRegexp(guidPattern))
+
+		// The hostname comes from the drain's binding, which is refreshed
+		// on the drain reconcile interval rather than on restart, so the
+		// drain is watched until a message has the expected one.
+		hostname := fmt.Sprintf("%s.%s.%s", org, space, name)
+		line, err = drainLogs.WaitFor(func(line string) bool {
+			m, ok := ParseSyslog(line)
+			return ok && m.Hostname == hostname && strings.Contains(line, marker)
+		}, timeout)
+		Expect(err).ToNot(HaveOccurred(), "drain with hostname %s", hostname)
+
+		m, _ := ParseSyslog(line)
+		Expect(m.AppName).To(Equal(appGUID))
+		Expect(m.ProcID).To(Equal("[APP/PROC/WEB/0]"))
+
+		Expect(m.StructuredData).To(HaveKey("tags@47450"))
+		tags := m.StructuredData["tags@47450"]
+		Expect(tags["app_name"]).To(Equal(name))
+		Expect(tags["space_name"]).To(Equal(space))
+		Expect(tags["organization_name"]).To(Equal(org))
+		Expect(tags["process_type"]).To(Equal("web"))
+		Expect(tags["instance_id"]).To(Equal("0"))
+		Expect(tags["process_instance_id"]).To(Equal(e.Tags["process_instance_id"]))
+	}
+
+	It("tags app logs with the app's org, space, name, process and instance", func() {
+		expectTags(appName)
+	})
+
+	It("tags app logs with the new name once a renamed app is restarted", func() {
+		oldName := appName
+		expectTags(oldName)
+
+		newName := generator.PrefixedRandomName(TestPrefix, "RENAMED")
+		CF("rename", appName, newName)
+		appName = newName
+
+		CFWithTimeout(cli.Config().AppPushTimeout, "restart", appName)
+
+		expectTags(newName)
+	})
+})
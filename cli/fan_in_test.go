@@ -0,0 +1,238 @@
+package cli_test
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FanIn", func() {
+
+	const (
+		burstLines = 100
+
+		// logInterval keeps the apps' own lines, which show when an app
+		// is bound, light at the listener.
+		logInterval = 5 * time.Second
+
+		// messageBytes is a generous size for one drained line, syslog
+		// header and tags included.
+		messageBytes = 1 << 10
+
+		// The listener counts lines by app, and by app and sequence.
+		appGroup   = `APP_LOG: (\S+) `
+		burstGroup = `BURST_LOG: (\S+) instance=\S* seq=(\d+)`
+	)
+
+	var (
+		scaleSpace string
+		home       *Home
+		listener   string
+		drainName  string
+		prefix     string
+		apps       []string
+	)
+
+	BeforeEach(func() {
+		scaleSpace = ""
+		home = nil
+		listener = ""
+		drainName = ""
+		apps = nil
+
+		if cli.Config().FanInApps == 0 {
+			Skip("FAN_IN_APPS is not set")
+		}
+
+		// The apps get their own space so the space drain binds nothing
+		// else, in particular not the listener. It is targeted in a home of
+		// its own so the suite's target never changes.
+		scaleSpace = generator.PrefixedRandomName(TestPrefix, "fan-in-space")
+		CF("create-space", scaleSpace, "-o", org)
+		home = NewHome(org, scaleSpace)
+
+		prefix = generator.PrefixedRandomName("FAN-IN", "")
+
+		// The apps get a listener of their own, which keeps only their
+		// lines and has room for every one logged while the spec runs.
+		cfg := cli.Config()
+		linesPerApp := burstLines + int((cfg.DrainReconcileTimeout+cfg.DefaultTimeout)/logInterval)
+		storeBytes := cfg.FanInApps * linesPerApp * messageBytes
+		listener = PushSyslogServerWithEnv(map[string]string{
+			"MAX_STORED_BYTES": strconv.Itoa(storeBytes),
+			"STORE_MATCH":      regexp.QuoteMeta(prefix + "-"),
+			"PRINT_MESSAGES":   "false",
+		}, 64+2*(storeBytes>>20))
+	})
+
+	AfterEach(func() {
+		if scaleSpace == "" {
+			return
+		}
+
+		if home != nil {
+			if drainName != "" {
+				home.Cf("delete-drain-space", drainName, "--force").Wait(cli.Config().DefaultTimeout)
+			}
+			home.Remove()
+		}
+
+		cf.Cf("delete-space", scaleSpace, "-o", org, "-f").Wait(cli.Config().AppPushTimeout)
+
+		if listener != "" {
+			cf.Cf("delete", listener, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		}
+	})
+
+	// pushApps pushes the apps in parallel, starting at most one push every
+	// FanInPushInterval and running at most FanInPushConcurrency at once.
+	pushApps := func(n int) []string {
+		cfg := cli.Config()
+
+		names := make([]string, n)
+		for i := range names {
+			names[i] = fmt.Sprintf("%s-%d", prefix, i)
+		}
+
+		env := map[string]string{"LOG_INTERVAL": logInterval.String()}
+
+		throttle := time.NewTicker(cfg.FanInPushInterval)
+		defer throttle.Stop()
+
+		var wg sync.WaitGroup
+		pushing := make(chan struct{}, cfg.FanInPushConcurrency)
+		for _, name := range names {
+			pushing <- struct{}{}
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				defer func() { <-pushing }()
+				defer GinkgoRecover()
+
+				home.PushConstantLoggerNamed(name, env)
+			}(name)
+			<-throttle.C
+		}
+		wg.Wait()
+
+		return names
+	}
+
+	// burstSequences groups the burst lines at the listener that contain
+	// substr by app, then by sequence number.
+	burstSequences := func(substr string) map[string]map[uint64]bool {
+		seqs := map[string]map[uint64]bool{}
+		for key := range DrainedCounts(listener, "", substr, burstGroup) {
+			fields := strings.Fields(key)
+			if len(fields) != 2 {
+				continue
+			}
+
+			seq, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				continue
+			}
+
+			if seqs[fields[0]] == nil {
+				seqs[fields[0]] = map[uint64]bool{}
+			}
+			seqs[fields[0]][seq] = true
+		}
+		return seqs
+	}
+
+	It("delivers every app's logs through one space drain", func() {
+		cfg := cli.Config()
+		apps = pushApps(cfg.FanInApps)
+
+		drainName = generator.PrefixedRandomName("FAN-IN", "DRAIN")
+		start := time.Now()
+		home.CFWithTimeout(
+			1*time.Minute,
+			"drain-space",
+			fmt.Sprintf("https://%s.%s", listener, cfg.CFDomain),
+			"--drain-name", drainName,
+			"--path", SpaceDrainDir(),
+		)
+
+		// An app counts as bound once its first line reaches the listener,
+		// so the times are only as precise as the poll and log intervals.
+		bound := map[string]time.Duration{}
+		for time.Since(start) < cfg.DrainReconcileTimeout && len(bound) < len(apps) {
+			for app := range DrainedCounts(listener, "", "APP_LOG: "+prefix+"-", appGroup) {
+				if _, ok := bound[app]; !ok {
+					bound[app] = time.Since(start)
+				}
+			}
+			time.Sleep(5 * time.Second)
+		}
+
+		var times []time.Duration
+		for _, d := range bound {
+			times = append(times, d)
+		}
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+		if len(times) > 0 {
+			fmt.Fprintf(
+				GinkgoWriter,
+				"bind reconcile: %d of %d apps, min %s, median %s, max %s\n",
+				len(times), len(apps), times[0], times[len(times)/2], times[len(times)-1],
+			)
+		}
+
+		var unbound []string
+		for _, app := range apps {
+			if _, ok := bound[app]; !ok {
+				unbound = append(unbound, app)
+			}
+		}
+		Expect(unbound).To(BeEmpty(), "apps never delivered to the space drain")
+
+		id := generator.PrefixedRandomName("FAN-IN", "BURST")
+		var wg sync.WaitGroup
+		commanding := make(chan struct{}, cfg.FanInPushConcurrency)
+		for _, app := range apps {
+			commanding <- struct{}{}
+			wg.Add(1)
+			go func(app string) {
+				defer wg.Done()
+				defer func() { <-commanding }()
+				defer GinkgoRecover()
+
+				home.CommandConstantLogger(app, 0, fmt.Sprintf("/burst/%d?id=%s", burstLines, id))
+			}(app)
+		}
+		wg.Wait()
+
+		var incomplete map[string]int
+		Eventually(func() map[string]int {
+			// Evicted lines would count as lost.
+			Expect(DrainEvictions(listener, "")).To(BeZero(), "the listener evicted lines before they were counted")
+
+			seqs := burstSequences("id=" + id + "-")
+
+			incomplete = map[string]int{}
+			for _, app := range apps {
+				if missing := len(MissingSequences(seqs[app], burstLines)); missing > 0 {
+					incomplete[app] = missing
+				}
+			}
+			return incomplete
+		}, cfg.DefaultTimeout, 5*time.Second).Should(BeEmpty(), "missing lines per app")
+
+		fmt.Fprintf(GinkgoWriter, "completeness: %d of %d apps delivered all %d lines\n",
+			len(apps)-len(incomplete), len(apps), burstLines)
+	})
+})
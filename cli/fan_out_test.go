@@ -0,0 +1,123 @@
+package cli_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FanOut", func() {
+
+	const burstLines = 500
+
+	var (
+		appName string
+		drains  []string
+	)
+
+	BeforeEach(func() {
+		drains = nil
+
+		// LOG_COUNT keeps the app's own lines out of the burst.
+		appName = PushConstantLogger(map[string]string{"LOG_COUNT": "1"})
+	})
+
+	AfterEach(func() {
+		for _, drain := range drains {
+			cf.Cf("delete-drain", drain, "--force").Wait(cli.Config().DefaultTimeout)
+		}
+
+		cf.Cf("delete", appName, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+	})
+
+	// bindDrains binds the app to one drain per path on the listener.
+	bindDrains := func(paths ...string) {
+		for _, path := range paths {
+			drainName := generator.PrefixedRandomName("FAN-OUT", "DRAIN")
+			drains = append(drains, drainName)
+
+			CF(
+				"drain",
+				appName,
+				fmt.Sprintf("https://%s.%s%s", listenerAppName, cli.Config().CFDomain, path),
+				"--drain-name", drainName,
+			)
+		}
+	}
+
+	// healthyPaths returns n listener paths that are unique to this run.
+	healthyPaths := func(n int) []string {
+		prefix := strings.ToLower(generator.PrefixedRandomName("fan-out", ""))
+
+		var paths []string
+		for i := 0; i < n; i++ {
+			paths = append(paths, fmt.Sprintf("/%s-%d", prefix, i))
+		}
+		return paths
+	}
+
+	// awaitDelivery emits probes until every path has received one.
+	awaitDelivery := func(paths []string) {
+		probe := generator.PrefixedRandomName("FAN-OUT-PROBE", "")
+
+		Eventually(func() []string {
+			EmitConstantLogger(appName, []byte(probe))
+
+			var waiting []string
+			for _, path := range paths {
+				if len(DrainedMessagesAt(listenerAppName, path, probe)) == 0 {
+					waiting = append(waiting, path)
+				}
+			}
+			return waiting
+		}, cli.Config().DrainReconcileTimeout, 5*time.Second).Should(BeEmpty(), "drains never delivered")
+	}
+
+	// expectFullSequence emits a burst and waits for every path to receive
+	// every line of it.
+	expectFullSequence := func(paths []string) {
+		id := generator.PrefixedRandomName("FAN-OUT", "")
+		CommandConstantLogger(appName, 0, fmt.Sprintf("/burst/%d?id=%s", burstLines, id))
+
+		for _, path := range paths {
+			Eventually(func() []uint64 {
+				seen := map[uint64]bool{}
+				for _, msg := range DrainedMessagesAt(listenerAppName, path, "id="+id+"-") {
+					if seq, ok := ParseSequence(string(msg), "BURST_LOG", appName); ok {
+						seen[seq] = true
+					}
+				}
+				return MissingSequences(seen, burstLines)
+			}, cli.Config().DefaultTimeout, 5*time.Second).Should(BeEmpty(), "drain to %s", path)
+		}
+	}
+
+	It("delivers the full sequence to each of many drains", func() {
+		paths := healthyPaths(cli.Config().FanOutDrains)
+		bindDrains(paths...)
+
+		awaitDelivery(paths)
+		expectFullSequence(paths)
+	})
+
+	DescribeTable("keeps delivering to healthy drains while one is faulted",
+		func(faultPath string) {
+			paths := healthyPaths(cli.Config().FanOutDrains - 1)
+			bindDrains(append(paths, faultPath)...)
+
+			awaitDelivery(paths)
+			expectFullSequence(paths)
+		},
+		Entry("when it refuses messages", "/fault/error"),
+		Entry("when it never answers", "/fault/hang"),
+	)
+})
@@ -0,0 +1,58 @@
+package helpers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var accessLogRegex = regexp.MustCompile(
+	`\[RTR/\d+\]\s+OUT .*"(\S+) (\S+) HTTP/[\d.]+" (\d{3}) .*vcap_request_id:"([^"]+)".* response_time:([\d.]+)`,
+)
+
+// AccessLog is the part of a gorouter access log line the specs check.
+type AccessLog struct {
+	Method       string
+	Path         string
+	StatusCode   int
+	RequestID    string
+	ResponseTime float64
+}
+
+// ParseAccessLog parses an RTR line from cf logs output.
+func ParseAccessLog(line string) (AccessLog, bool) {
+	m := accessLogRegex.FindStringSubmatch(line)
+	if m == nil {
+		return AccessLog{}, false
+	}
+
+	status, err := strconv.Atoi(m[3])
+	if err != nil {
+		return AccessLog{}, false
+	}
+
+	responseTime, err := strconv.ParseFloat(m[5], 64)
+	if err != nil {
+		return AccessLog{}, false
+	}
+
+	return AccessLog{
+		Method:       m[1],
+		Path:         m[2],
+		StatusCode:   status,
+		RequestID:    m[4],
+		ResponseTime: responseTime,
+	}, true
+}
+
+// AccessLogsByRequestID returns the RTR lines in cf logs output, keyed by
+// X-Vcap-Request-Id.
+func AccessLogsByRequestID(output string) map[string]AccessLog {
+	logs := map[string]AccessLog{}
+	for _, line := range strings.Split(output, "\n") {
+		if l, ok := ParseAccessLog(line); ok {
+			logs[l.RequestID] = l
+		}
+	}
+	return logs
+}
@@ -1,6 +1,10 @@
 package helpers
 
 import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
@@ -27,3 +31,76 @@ func CFWithTimeout(timeout time.Duration, args ...string) {
 func Drains() *Session {
 	return cf.Cf("drains").Wait(cli.Config().DefaultTimeout)
 }
+
+func HasDrain(appName, drainName string) bool {
+	s := Drains()
+	if s.ExitCode() != 0 {
+		return false
+	}
+
+	// The app name is the first column and the drain name the second.
+	for _, line := range strings.Split(string(s.Out.Contents()), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == appName && fields[1] == drainName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func AppGUID(appName string) string {
+	s := cf.Cf("app", appName, "--guid").Wait(cli.Config().DefaultTimeout)
+	ExpectWithOffset(1, s).To(Exit(0), "Failed to get GUID of "+appName)
+
+	return strings.TrimSpace(string(s.Out.Contents()))
+}
+
+// ScaleApp scales an app to the given number of instances and waits until
+// they are all running.
+func ScaleApp(appName string, instances int) {
+	cfg := cli.Config()
+
+	s := cf.Cf("scale", appName, "-i", strconv.Itoa(instances))
+	EventuallyWithOffset(1, s, cfg.DefaultTimeout).Should(Exit(0), "Failed to scale "+appName)
+
+	EventuallyWithOffset(1, func() int {
+		return RunningInstances(appName)
+	}, cfg.AppPushTimeout, 2*time.Second).Should(Equal(instances))
+}
+
+// RunningInstances returns how many instances of an app are running.
+func RunningInstances(appName string) int {
+	s := cf.Cf("app", appName).Wait(cli.Config().DefaultTimeout)
+	if s.ExitCode() != 0 {
+		return 0
+	}
+
+	return len(runningInstanceRegex.FindAll(s.Out.Contents(), -1))
+}
+
+var runningInstanceRegex = regexp.MustCompile(`(?m)^#\d+\s+running`)
+
+// InstanceHosts returns the cell address of each running instance of an
+// app's web process.
+func InstanceHosts(appName string) []string {
+	s := cf.Cf("curl", "/v3/apps/"+AppGUID(appName)+"/processes/web/stats").Wait(cli.Config().DefaultTimeout)
+	ExpectWithOffset(1, s).To(Exit(0), "Failed to get stats of "+appName)
+
+	var stats struct {
+		Resources []struct {
+			State string `json:"state"`
+			Host  string `json:"host"`
+		} `json:"resources"`
+	}
+	ExpectWithOffset(1, json.Unmarshal(s.Out.Contents(), &stats)).To(Succeed())
+
+	var hosts []string
+	for _, r := range stats.Resources {
+		if r.State == "RUNNING" && r.Host != "" {
+			hosts = append(hosts, r.Host)
+		}
+	}
+
+	return hosts
+}
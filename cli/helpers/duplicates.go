@@ -0,0 +1,100 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var messageIDRegex = regexp.MustCompile(`\bid=(\S+)`)
+
+// ParseMessageID returns the unique ID of a line, the value of its id=
+// field. The line may carry any prefix, such as cf logs or syslog headers.
+func ParseMessageID(line string) (string, bool) {
+	m := messageIDRegex.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+
+	return m[1], true
+}
+
+// DuplicateReport counts how often uniquely identified messages were
+// delivered on one egress path.
+type DuplicateReport struct {
+	Path string
+
+	// Emitted is how many messages were sent, Delivered how many arrived
+	// counting duplicates, and Unique how many distinct ones arrived.
+	Emitted   int
+	Delivered int
+	Unique    int
+}
+
+// CountDuplicates builds the report for the IDs delivered on path.
+func CountDuplicates(path string, emitted int, ids []string) DuplicateReport {
+	seen := map[string]bool{}
+	for _, id := range ids {
+		seen[id] = true
+	}
+
+	return DuplicateReport{
+		Path:      path,
+		Emitted:   emitted,
+		Delivered: len(ids),
+		Unique:    len(seen),
+	}
+}
+
+// Duplicates returns how many deliveries were repeats.
+func (r DuplicateReport) Duplicates() int {
+	return r.Delivered - r.Unique
+}
+
+// Rate returns the fraction of deliveries that were repeats.
+func (r DuplicateReport) Rate() float64 {
+	if r.Delivered == 0 {
+		return 0
+	}
+
+	return float64(r.Duplicates()) / float64(r.Delivered)
+}
+
+func (r DuplicateReport) String() string {
+	return fmt.Sprintf(
+		"%-16s emitted=%d delivered=%d unique=%d duplicates=%d rate=%.4f%%",
+		r.Path, r.Emitted, r.Delivered, r.Unique, r.Duplicates(), 100*r.Rate(),
+	)
+}
+
+var (
+	duplicateMu      sync.Mutex
+	duplicateReports []DuplicateReport
+)
+
+// RecordDuplicates keeps a report for DuplicateSummary.
+func RecordDuplicates(r DuplicateReport) {
+	duplicateMu.Lock()
+	defer duplicateMu.Unlock()
+
+	duplicateReports = append(duplicateReports, r)
+}
+
+// DuplicateSummary returns the recorded reports, one per line, or an empty
+// string if there are none.
+func DuplicateSummary() string {
+	duplicateMu.Lock()
+	defer duplicateMu.Unlock()
+
+	if len(duplicateReports) == 0 {
+		return ""
+	}
+
+	lines := []string{"Duplicate deliveries:"}
+	for _, r := range duplicateReports {
+		lines = append(lines, "  "+r.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,113 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+// Home runs cf as the admin with a CF_HOME of its own. It targets another
+// org and space without changing the suite's target, which every other spec
+// relies on. Plugins are shared with the suite.
+type Home struct {
+	dir string
+	env []string
+}
+
+// NewHome logs the admin in with a new CF_HOME and targets org and space.
+func NewHome(org, space string) *Home {
+	cfg := cli.Config()
+
+	dir, err := ioutil.TempDir("", "cf-admin-home")
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	h := &Home{
+		dir: dir,
+		env: []string{
+			"CF_HOME=" + dir,
+			"CF_PLUGIN_HOME=" + pluginHome(),
+		},
+	}
+
+	api := []string{"api", "https://api." + cfg.CFDomain}
+	if cfg.SkipCertVerify {
+		api = append(api, "--skip-ssl-validation")
+	}
+	EventuallyWithOffset(1, h.Cf(api...), cfg.DefaultTimeout).Should(Exit(0), "Failed to set API")
+	EventuallyWithOffset(1, h.Cf("auth", cfg.CFAdminUser, cfg.CFAdminPassword), cfg.DefaultTimeout).Should(Exit(0), "Failed to log in")
+	EventuallyWithOffset(1, h.Cf("target", "-o", org, "-s", space), cfg.DefaultTimeout).Should(Exit(0), "Failed to target "+org+"/"+space)
+
+	return h
+}
+
+// Cf runs cf in the home.
+func (h *Home) Cf(args ...string) *Session {
+	command := strings.Replace("cf "+strings.Join(args, " "), cli.Config().CFAdminPassword, "[REDACTED]", -1)
+
+	s, err := startQuiet(cfCommand(h.env, args...), command)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	return s
+}
+
+// CF is CF in the home.
+func (h *Home) CF(args ...string) {
+	EventuallyWithOffset(1, h.Cf(args...), cli.Config().DefaultTimeout).Should(Exit(0))
+}
+
+// CFWithTimeout is CFWithTimeout in the home.
+func (h *Home) CFWithTimeout(timeout time.Duration, args ...string) {
+	EventuallyWithOffset(1, h.Cf(args...), timeout).Should(Exit(0))
+}
+
+// AppGUID is AppGUID in the home.
+func (h *Home) AppGUID(appName string) string {
+	s := h.Cf("app", appName, "--guid").Wait(cli.Config().DefaultTimeout)
+	ExpectWithOffset(1, s).To(Exit(0), "Failed to get GUID of "+appName)
+
+	return strings.TrimSpace(string(s.Out.Contents()))
+}
+
+// PushSyslogServer is PushSyslogServer in the home.
+func (h *Home) PushSyslogServer() string {
+	return pushSyslogServer(h.Cf, nil, 64)
+}
+
+// PushConstantLogger is PushConstantLogger in the home.
+func (h *Home) PushConstantLogger(env map[string]string, pushArgs ...string) string {
+	appName := generator.PrefixedRandomName("CONSTANT-LOGGER", "")
+	pushConstantLogger(h.Cf, appName, env, pushArgs...)
+
+	return appName
+}
+
+// PushConstantLoggerNamed is PushConstantLoggerNamed in the home.
+func (h *Home) PushConstantLoggerNamed(appName string, env map[string]string, pushArgs ...string) {
+	pushConstantLogger(h.Cf, appName, env, pushArgs...)
+}
+
+// CommandConstantLogger is CommandConstantLogger in the home.
+func (h *Home) CommandConstantLogger(appName string, index int, command string) {
+	commandConstantLogger(appName, h.AppGUID(appName), index, command)
+}
+
+// LogsTail is LogsTail in the home.
+func (h *Home) LogsTail(appName string) *Session {
+	return h.Cf("logs", appName, "--recent")
+}
+
+// LogsFollowLines is LogsFollowLines in the home.
+func (h *Home) LogsFollowLines(appName string) *LineStream {
+	return startLineStream(h.env, "logs", appName)
+}
+
+// Remove removes the home. The admin stays logged in elsewhere.
+func (h *Home) Remove() {
+	os.RemoveAll(h.dir)
+}
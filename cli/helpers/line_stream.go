@@ -0,0 +1,265 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// recentLines is how many lines a LineStream keeps for diagnostics.
+	recentLines = 1000
+
+	// maxLineBytes is the longest line a LineStream can read.
+	maxLineBytes = 4 * 1024 * 1024
+)
+
+// LineStream consumes the output of a long running cf command line by line
+// as it arrives. Unlike a gexec.Session it does not buffer the whole output,
+// only a bounded ring of recent lines, so it is safe to use for soak-length
+// specs.
+type LineStream struct {
+	command string
+	cmd     *exec.Cmd
+	stderr  *capture
+	done    chan struct{}
+
+	// failed is closed when the output can't be read as lines, after
+	// readErr is set.
+	failed chan struct{}
+
+	mu       sync.Mutex
+	recent   []string
+	next     int
+	count    int64
+	readErr  error
+	waiters  []*lineWaiter
+	handlers []func(string)
+}
+
+type lineWaiter struct {
+	match func(string) bool
+	found chan string
+}
+
+// LogsFollowLines streams `cf logs <app>`.
+func LogsFollowLines(appName string) *LineStream {
+	return startLineStream(nil, "logs", appName)
+}
+
+// LogStreamLines streams `cf log-stream <args>`.
+func LogStreamLines(args ...string) *LineStream {
+	return startLineStream(nil, append([]string{"log-stream"}, args...)...)
+}
+
+func startLineStream(env []string, args ...string) *LineStream {
+	s := &LineStream{
+		command: "cf " + strings.Join(args, " "),
+		cmd:     cfCommand(env, args...),
+		done:    make(chan struct{}),
+		failed:  make(chan struct{}),
+		recent:  make([]string, 0, recentLines),
+	}
+	addReportable(s)
+
+	s.stderr = &capture{command: s.command + " (stderr)"}
+	addReportable(s.stderr)
+	s.cmd.Stderr = s.stderr
+
+	stdout, err := s.cmd.StdoutPipe()
+	ExpectWithOffset(2, err).ToNot(HaveOccurred())
+	ExpectWithOffset(2, s.cmd.Start()).To(Succeed())
+
+	go func() {
+		defer close(s.done)
+
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+		for scanner.Scan() {
+			s.add(scanner.Text())
+		}
+
+		// A line longer than maxLineBytes stops the scanner. The rest is
+		// discarded, so that cf never blocks writing to a full pipe.
+		if err := scanner.Err(); err != nil {
+			s.mu.Lock()
+			s.readErr = fmt.Errorf("failed to read %s after %d lines: %s", s.command, s.count, err)
+			s.mu.Unlock()
+			close(s.failed)
+
+			io.Copy(ioutil.Discard, stdout)
+		}
+
+		s.cmd.Wait()
+	}()
+
+	return s
+}
+
+// OnLine registers f to be called with every line that arrives from now on.
+// It is called from the goroutine reading the stream, in order.
+func (s *LineStream) OnLine(f func(line string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, f)
+}
+
+// WaitFor returns the first line that matches. Recent lines are checked
+// before waiting for new ones. It errors if nothing matches within timeout,
+// if the command exits or if its output can no longer be read.
+func (s *LineStream) WaitFor(match func(line string) bool, timeout time.Duration) (string, error) {
+	s.mu.Lock()
+	for _, line := range s.recentLocked() {
+		if match(line) {
+			s.mu.Unlock()
+			return line, nil
+		}
+	}
+	if s.readErr != nil {
+		s.mu.Unlock()
+		return "", s.readErr
+	}
+
+	w := &lineWaiter{match: match, found: make(chan string, 1)}
+	s.waiters = append(s.waiters, w)
+	s.mu.Unlock()
+
+	defer s.removeWaiter(w)
+
+	select {
+	case line := <-w.found:
+		return line, nil
+	case <-s.done:
+		select {
+		case line := <-w.found:
+			return line, nil
+		default:
+		}
+		if err := s.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%s exited before a matching line arrived", s.command)
+	case <-s.failed:
+		return "", s.Err()
+	case <-time.After(timeout):
+		return "", fmt.Errorf("timed out after %s waiting for a matching line from %s", timeout, s.command)
+	}
+}
+
+// Recent returns up to the last 1000 lines, oldest first.
+func (s *LineStream) Recent() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.recentLocked()
+}
+
+// Stderr returns what the command has written to stderr. Only the most
+// recent output is kept.
+func (s *LineStream) Stderr() string {
+	s.stderr.mu.Lock()
+	defer s.stderr.mu.Unlock()
+	return string(s.stderr.buf)
+}
+
+// Err returns why the output could no longer be read as lines, such as a
+// line longer than 4MiB, or nil.
+func (s *LineStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readErr
+}
+
+// Count returns the number of lines read so far.
+func (s *LineStream) Count() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// Kill stops the command and waits for the stream to finish.
+func (s *LineStream) Kill() {
+	s.cmd.Process.Kill()
+	<-s.done
+}
+
+func (s *LineStream) add(line string) {
+	s.mu.Lock()
+	if len(s.recent) < recentLines {
+		s.recent = append(s.recent, line)
+	} else {
+		s.recent[s.next] = line
+		s.next = (s.next + 1) % recentLines
+	}
+	s.count++
+
+	remaining := s.waiters[:0]
+	for _, w := range s.waiters {
+		if w.match(line) {
+			w.found <- line
+			continue
+		}
+		remaining = append(remaining, w)
+	}
+	s.waiters = remaining
+
+	handlers := s.handlers
+	s.mu.Unlock()
+
+	for _, f := range handlers {
+		f(line)
+	}
+}
+
+func (s *LineStream) removeWaiter(w *lineWaiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, other := range s.waiters {
+		if other == w {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *LineStream) recentLocked() []string {
+	lines := make([]string, 0, len(s.recent))
+	lines = append(lines, s.recent[s.next:]...)
+	return append(lines, s.recent[:s.next]...)
+}
+
+func (s *LineStream) report() string {
+	report := fmt.Sprintf("> %s (last %d of %d lines)\n%s\n",
+		s.command,
+		len(s.Recent()),
+		s.Count(),
+		strings.Join(s.Recent(), "\n"),
+	)
+	if err := s.Err(); err != nil {
+		report += err.Error() + "\n"
+	}
+	return report
+}
+
+// Containing matches lines that contain substr.
+func Containing(substr string) func(string) bool {
+	return func(line string) bool {
+		return strings.Contains(line, substr)
+	}
+}
+
+// Matching matches lines that match the regular expression pattern.
+func Matching(pattern string) func(string) bool {
+	re := regexp.MustCompile(pattern)
+	return func(line string) bool {
+		return re.MatchString(line)
+	}
+}
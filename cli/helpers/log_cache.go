@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/logcache"
+	. "github.com/onsi/gomega"
+)
+
+// Envelope is the JSON form of a loggregator v2 envelope as returned by the
+// Log Cache HTTP API and printed by `cf log-stream`.
+type Envelope = logcache.Envelope
+
+// ParseEnvelope decodes a line of `cf log-stream` output.
+func ParseEnvelope(line string) (Envelope, bool) {
+	var e Envelope
+	if err := json.Unmarshal([]byte(line), &e); err != nil {
+		return Envelope{}, false
+	}
+	return e, true
+}
+
+// LogCacheReadAll pages through every envelope Log Cache holds for a source
+// ID, oldest first. The query must not set start_time or limit.
+func LogCacheReadAll(sourceID string, query url.Values) []Envelope {
+	envelopes, err := logCacheClient().ReadAll(sourceID, query)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	return envelopes
+}
+
+func logCacheClient() *logcache.Client {
+	cfg := cli.Config()
+	return logcache.NewClient(cfg.CFDomain, cfg.SkipCertVerify, cfg.DefaultTimeout)
+}
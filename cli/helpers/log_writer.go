@@ -0,0 +1,254 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+)
+
+// LogWriter repeatedly asks a log emitter app to log a message until its
+// context is done. Errors are never asserted from the background goroutine;
+// they are returned from Wait instead.
+type LogWriter struct {
+	// Accessed atomically, keep first for 64-bit alignment.
+	sent      int64
+	succeeded int64
+	failed    int64
+
+	url      string
+	header   http.Header
+	interval time.Duration
+	retries  int
+	backoff  time.Duration
+	client   *http.Client
+
+	done chan struct{}
+
+	mu       sync.Mutex
+	err      error
+	requests []RouterRequest
+}
+
+// maxRequests is how many requests a writer keeps for Requests.
+const maxRequests = 1000
+
+// RouterRequest is a request that got a response, whatever its status code.
+type RouterRequest struct {
+	// ID is the X-Vcap-Request-Id the router assigned, as echoed by the app.
+	// It is empty if the router answered without reaching the app.
+	ID         string
+	Method     string
+	Path       string
+	StatusCode int
+	Duration   time.Duration
+}
+
+type LogWriterOption func(*LogWriter)
+
+// WithInterval sets how long the writer waits between messages. Defaults to
+// 3 seconds.
+func WithInterval(d time.Duration) LogWriterOption {
+	return func(w *LogWriter) {
+		w.interval = d
+	}
+}
+
+// WithClientTimeout sets the timeout of every HTTP request. Defaults to 10
+// seconds.
+func WithClientTimeout(d time.Duration) LogWriterOption {
+	return func(w *LogWriter) {
+		w.client.Timeout = d
+	}
+}
+
+// WithRetries sets how many times a message is retried after a transient
+// error before it is reported. Defaults to 3.
+func WithRetries(n int) LogWriterOption {
+	return func(w *LogWriter) {
+		w.retries = n
+	}
+}
+
+// WithAppInstance routes every request to a single instance of the app.
+func WithAppInstance(appGUID string, index int) LogWriterOption {
+	return func(w *LogWriter) {
+		w.header.Set("X-Cf-App-Instance", fmt.Sprintf("%s:%d", appGUID, index))
+	}
+}
+
+func NewLogWriter(appName, message string, opts ...LogWriterOption) *LogWriter {
+	cfg := cli.Config()
+
+	w := &LogWriter{
+		url:      fmt.Sprintf("http://%s.%s/log/%s", appName, cfg.CFDomain, message),
+		header:   http.Header{},
+		interval: 3 * time.Second,
+		retries:  3,
+		backoff:  time.Second,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		done: make(chan struct{}),
+	}
+
+	for _, o := range opts {
+		o(w)
+	}
+
+	return w
+}
+
+// StartLogWriter creates a LogWriter and starts it with the given context.
+func StartLogWriter(ctx context.Context, message, appName string, opts ...LogWriterOption) *LogWriter {
+	w := NewLogWriter(appName, message, opts...)
+	w.Start(ctx)
+	return w
+}
+
+// Start writes messages in a new goroutine until ctx is done. It must only be
+// called once.
+func (w *LogWriter) Start(ctx context.Context) {
+	go w.run(ctx)
+}
+
+// Wait blocks until the writer has stopped and returns the first error that
+// could not be retried. It blocks forever if Start was never called.
+func (w *LogWriter) Wait() error {
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Requests returns the last requests that got a response, including those
+// that failed with a non-200 status code.
+func (w *LogWriter) Requests() []RouterRequest {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]RouterRequest(nil), w.requests...)
+}
+
+// Sent returns the number of requests issued, including retries.
+func (w *LogWriter) Sent() int64 {
+	return atomic.LoadInt64(&w.sent)
+}
+
+// Succeeded returns the number of requests answered with 200 OK.
+func (w *LogWriter) Succeeded() int64 {
+	return atomic.LoadInt64(&w.succeeded)
+}
+
+// Failed returns the number of requests that errored or returned a non-200
+// status code.
+func (w *LogWriter) Failed() int64 {
+	return atomic.LoadInt64(&w.failed)
+}
+
+func (w *LogWriter) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.write(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *LogWriter) write(ctx context.Context) {
+	var err error
+	for attempt := 0; attempt <= w.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Duration(attempt) * w.backoff):
+			}
+		}
+
+		atomic.AddInt64(&w.sent, 1)
+		err = w.get(ctx)
+		if err == nil {
+			atomic.AddInt64(&w.succeeded, 1)
+			return
+		}
+
+		// Requests cut short by the context are not failures.
+		if ctx.Err() != nil {
+			return
+		}
+
+		atomic.AddInt64(&w.failed, 1)
+		if _, ok := err.(transientError); !ok {
+			break
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *LogWriter) get(ctx context.Context) error {
+	req, err := http.NewRequest(http.MethodGet, w.url, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range w.header {
+		req.Header[k] = v
+	}
+
+	start := time.Now()
+	resp, err := w.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return transientError{err}
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	w.mu.Lock()
+	w.requests = append(w.requests, RouterRequest{
+		ID:         resp.Header.Get("X-Vcap-Request-Id"),
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		StatusCode: resp.StatusCode,
+		Duration:   time.Since(start),
+	})
+	if len(w.requests) > maxRequests {
+		w.requests = w.requests[len(w.requests)-maxRequests:]
+	}
+	w.mu.Unlock()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("GET %s returned %d", w.url, resp.StatusCode)
+
+		switch resp.StatusCode {
+		// The gorouter answers 404 and 502 while an app restarts.
+		case http.StatusNotFound, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return transientError{err}
+		}
+		return err
+	}
+
+	return nil
+}
+
+type transientError struct {
+	error
+}
@@ -5,56 +5,30 @@ import (
 	"io/ioutil"
 	"net/http"
 	"regexp"
-	"time"
 
 	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
 	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
 	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
-	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gexec"
 )
 
 var (
-	logEmitterApp = "../apps/ruby_simple"
-	syslogDrain   = "../apps/syslog-drain-listener"
+	logEmitterApp  = "../apps/ruby_simple"
+	syslogDrain    = "../apps/syslog-drain-listener"
+	constantLogger = "../apps/constant-logger"
 )
 
-func SilienceGinkgoWriter(f func()) {
-	oldWriter := GinkgoWriter
-	defer func() {
-		GinkgoWriter = oldWriter
-	}()
-	GinkgoWriter = ioutil.Discard
-	f()
-}
-
 func LogsTail(appName string) *Session {
-	var s *Session
-	SilienceGinkgoWriter(func() {
-		s = cf.Cf("logs", appName, "--recent")
-	})
-
-	return s
+	return quietCf("logs", appName, "--recent")
 }
 
 func LogsFollow(appName string) *Session {
-	var s *Session
-	SilienceGinkgoWriter(func() {
-		s = cf.Cf("logs", appName)
-	})
-
-	return s
+	return quietCf("logs", appName)
 }
 
 func LogStream(args ...string) *Session {
-	var s *Session
-	SilienceGinkgoWriter(func() {
-		args = append([]string{"log-stream"}, args...)
-		s = cf.Cf(args...)
-	})
-
-	return s
+	return quietCf(append([]string{"log-stream"}, args...)...)
 }
 
 func PushLogWriter() string {
@@ -74,38 +48,150 @@ func PushLogWriter() string {
 }
 
 func PushSyslogServer() string {
+	return pushSyslogServer(cf.Cf, nil, 64)
+}
+
+// PushSyslogServerWithEnv pushes a syslog drain listener with the given
+// environment, such as MAX_STORED_BYTES, STORE_MATCH or PRINT_MESSAGES,
+// and memory in MiB. Specs that drain more than the shared listener can
+// keep use one of their own.
+func PushSyslogServerWithEnv(env map[string]string, memoryMiB int) string {
+	return pushSyslogServer(cf.Cf, env, memoryMiB)
+}
+
+func pushSyslogServer(run func(args ...string) *Session, env map[string]string, memoryMiB int) string {
 	cfg := cli.Config()
 	appName := generator.PrefixedRandomName("SYSLOG-SERVER", "")
 
-	session := cf.Cf(
+	session := run(
 		"push",
 		appName,
+		"--no-start",
 		"--health-check-type", "port",
 		"-p", syslogDrain,
 		"-b", "go_buildpack",
 		"-f", syslogDrain+"/manifest.yml",
-		"-m", "64M",
+		"-m", fmt.Sprintf("%dM", memoryMiB),
 	)
-	EventuallyWithOffset(1, func() *Session {return session}, cfg.AppPushTimeout).Should(Exit(0), "Failed to push app")
+	EventuallyWithOffset(2, func() *Session {return session}, cfg.AppPushTimeout).Should(Exit(0), "Failed to push app")
+
+	for k, v := range env {
+		session = run("set-env", appName, k, v)
+		EventuallyWithOffset(2, session, cfg.DefaultTimeout).Should(Exit(0), "Failed to set "+k)
+	}
+
+	session = run("start", appName)
+	EventuallyWithOffset(2, session, cfg.AppPushTimeout).Should(Exit(0), "Failed to start app")
+
+	return appName
+}
+
+// PushConstantLogger pushes and starts the constant-logger app with the given
+// environment. Extra push arguments, such as "-i", are appended to cf push.
+func PushConstantLogger(env map[string]string, pushArgs ...string) string {
+	appName := generator.PrefixedRandomName("CONSTANT-LOGGER", "")
+	pushConstantLogger(cf.Cf, appName, env, pushArgs...)
 
 	return appName
 }
 
-func WriteToLogsApp(doneChan chan struct{}, message, logWriterAppName string) {
+// PushConstantLoggerNamed is PushConstantLogger for a given app name.
+func PushConstantLoggerNamed(appName string, env map[string]string, pushArgs ...string) {
+	pushConstantLogger(cf.Cf, appName, env, pushArgs...)
+}
+
+func pushConstantLogger(run func(args ...string) *Session, appName string, env map[string]string, pushArgs ...string) {
 	cfg := cli.Config()
-	logUrl := fmt.Sprintf("http://%s.%s/log/%s", logWriterAppName, cfg.CFDomain, message)
-
-	defer GinkgoRecover()
-	for {
-		select {
-		case <-doneChan:
-			return
-		default:
-			resp, err := http.Get(logUrl)
-			ExpectWithOffset(1, err).ToNot(HaveOccurred())
-			ExpectWithOffset(1, resp.StatusCode).To(Equal(http.StatusOK))
-			time.Sleep(3 * time.Second)
-		}
+
+	args := append([]string{
+		"push",
+		appName,
+		"--no-start",
+		"-p", constantLogger,
+		"-b", "go_buildpack",
+		"-m", "64M",
+		// The app serves its commands on PORT as soon as it starts, but some
+		// specs make it exit or crash, so only the process is checked.
+		"-u", "process",
+	}, pushArgs...)
+
+	session := run(args...)
+	EventuallyWithOffset(2, session, cfg.AppPushTimeout).Should(Exit(0), "Failed to push app")
+
+	session = run(
+		"set-env",
+		appName,
+		"GOPACKAGENAME", "github.com/cloudfoundry/cfar-logging-acceptance-tests/apps/constant-logger",
+	)
+	EventuallyWithOffset(2, session, cfg.DefaultTimeout).Should(Exit(0), "Failed to set GOPACKAGENAME")
+
+	for k, v := range env {
+		session = run("set-env", appName, k, v)
+		EventuallyWithOffset(2, session, cfg.DefaultTimeout).Should(Exit(0), "Failed to set "+k)
+	}
+
+	session = run("start", appName)
+	EventuallyWithOffset(2, session, cfg.AppPushTimeout).Should(Exit(0), "Failed to start app")
+}
+
+// ConstantLoggerSidecarManifest writes a manifest for appName that runs a
+// second constant-logger, labelled SIDECAR_LOG, as a sidecar of the web
+// process. It returns the manifest's path, which the caller must remove.
+func ConstantLoggerSidecarManifest(appName, sidecarName string) string {
+	f, err := ioutil.TempFile("", "constant-logger-manifest")
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	defer f.Close()
+
+	// PORT is cleared so the sidecar does not listen on the web process's
+	// port.
+	_, err = fmt.Fprintf(f, `---
+applications:
+- name: %s
+  sidecars:
+  - name: %s
+    process_types: [web]
+    command: LOG_LABEL=SIDECAR_LOG PORT= constant-logger
+`, appName, sidecarName)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	return f.Name()
+}
+
+// CommandConstantLogger sends a command, such as "/memory/32", to one
+// instance of a constant-logger app.
+func CommandConstantLogger(appName string, index int, command string) {
+	commandConstantLogger(appName, AppGUID(appName), index, command)
+}
+
+func commandConstantLogger(appName, appGUID string, index int, command string) {
+	cfg := cli.Config()
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s.%s%s", appName, cfg.CFDomain, command), nil)
+	ExpectWithOffset(2, err).ToNot(HaveOccurred())
+	req.Header.Set("X-Cf-App-Instance", fmt.Sprintf("%s:%d", appGUID, index))
+
+	client := &http.Client{Timeout: cfg.DefaultTimeout}
+	resp, err := client.Do(req)
+	ExpectWithOffset(2, err).ToNot(HaveOccurred())
+	defer resp.Body.Close()
+
+	ExpectWithOffset(2, resp.StatusCode).To(Equal(http.StatusOK), "Failed to send "+command)
+}
+
+// CrashConstantLogger makes one instance of a constant-logger app exit with
+// status 1. The router's response is ignored since the instance exits before
+// answering.
+func CrashConstantLogger(appName string, index int) {
+	cfg := cli.Config()
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s.%s/crash", appName, cfg.CFDomain), nil)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	req.Header.Set("X-Cf-App-Instance", fmt.Sprintf("%s:%d", AppGUID(appName), index))
+
+	client := &http.Client{Timeout: cfg.DefaultTimeout}
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close()
 	}
 }
 
@@ -0,0 +1,115 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+// maxCapturedBytes bounds how much output is kept per session. Only the most
+// recent output is kept.
+const maxCapturedBytes = 64 * 1024
+
+var (
+	capturesMu sync.Mutex
+	captures   []reportable
+)
+
+// reportable is output that is written to the GinkgoWriter when a spec
+// fails.
+type reportable interface {
+	report() string
+}
+
+func addReportable(r reportable) {
+	capturesMu.Lock()
+	defer capturesMu.Unlock()
+	captures = append(captures, r)
+}
+
+// capture is an io.Writer that keeps the tail of a single session's output
+// so it can be reported if the spec fails.
+type capture struct {
+	command string
+
+	mu        sync.Mutex
+	buf       []byte
+	truncated bool
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.buf = append(c.buf, p...)
+	if len(c.buf) > maxCapturedBytes {
+		c.buf = append(c.buf[:0], c.buf[len(c.buf)-maxCapturedBytes:]...)
+		c.truncated = true
+	}
+
+	return len(p), nil
+}
+
+func (c *capture) report() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.truncated {
+		return fmt.Sprintf("> %s\n...\n%s", c.command, c.buf)
+	}
+	return fmt.Sprintf("> %s\n%s", c.command, c.buf)
+}
+
+// quietCf runs cf like cf.Cf, but writes the session's output to its own
+// capture instead of the GinkgoWriter. It is safe to call from multiple
+// goroutines.
+func quietCf(args ...string) *Session {
+	s, err := startQuiet(cfCommand(nil, args...), "cf "+strings.Join(args, " "))
+	ExpectWithOffset(2, err).ToNot(HaveOccurred())
+
+	return s
+}
+
+// startQuiet starts cmd with its output going to a capture reported under
+// the given command line.
+func startQuiet(cmd *exec.Cmd, command string) (*Session, error) {
+	c := &capture{command: command}
+	addReportable(c)
+
+	return Start(cmd, c, c)
+}
+
+// cfCommand returns a cf command that runs with env added to the suite's
+// environment.
+func cfCommand(env []string, args ...string) *exec.Cmd {
+	cmd := exec.Command("cf", args...)
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	return cmd
+}
+
+// ReportCapturedOutput writes the output captured by the helpers to the
+// GinkgoWriter if the current spec failed and then discards it. It should be
+// called from an AfterEach.
+func ReportCapturedOutput() {
+	capturesMu.Lock()
+	cs := captures
+	captures = nil
+	capturesMu.Unlock()
+
+	if !CurrentGinkgoTestDescription().Failed {
+		return
+	}
+
+	for _, c := range cs {
+		fmt.Fprintf(GinkgoWriter, "\n%s", c.report())
+	}
+}
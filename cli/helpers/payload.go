@@ -0,0 +1,206 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+	. "github.com/onsi/gomega"
+)
+
+// Payload is a body for constant-logger to write verbatim, and the log
+// messages it is expected to arrive as. Message i contains PayloadPart(i).
+type Payload struct {
+	Body     []byte
+	Messages [][]byte
+}
+
+// PayloadPart returns the text that identifies one expected message of the
+// payloads generated for marker.
+func PayloadPart(marker string, part int) string {
+	return fmt.Sprintf("%s part=%d ", marker, part)
+}
+
+// LargePayload returns a single ASCII line of size bytes. Lines longer than
+// maxLine are expected to be split into messages of maxLine bytes.
+func LargePayload(marker string, size, maxLine int) Payload {
+	var p Payload
+
+	for part := 0; len(p.Body) < size; part++ {
+		n := maxLine
+		if remaining := size - len(p.Body); remaining < n {
+			n = remaining
+		}
+
+		message := []byte(PayloadPart(marker, part))
+		ExpectWithOffset(1, len(message)).To(BeNumerically("<=", n), "payload too small for its markers")
+		message = append(message, bytes.Repeat([]byte("x"), n-len(message))...)
+
+		p.Body = append(p.Body, message...)
+		p.Messages = append(p.Messages, message)
+	}
+
+	return p
+}
+
+// RuneSplitPayload returns a line whose maxLine boundary falls inside a
+// two-byte character. The split is expected before that character, so
+// neither message has a partial character.
+func RuneSplitPayload(marker string, maxLine int) Payload {
+	first := []byte(PayloadPart(marker, 0))
+	first = append(first, bytes.Repeat([]byte("x"), maxLine-1-len(first))...)
+	second := []byte("é " + PayloadPart(marker, 1) + "ü")
+
+	return Payload{
+		Body:     append(append([]byte{}, first...), second...),
+		Messages: [][]byte{first, second},
+	}
+}
+
+// MultilinePayload returns several lines with tabs. Each line is expected
+// as its own message, with its tabs kept.
+func MultilinePayload(marker string) Payload {
+	lines := []string{
+		PayloadPart(marker, 0) + "col1\tcol2\t\tcol4",
+		PayloadPart(marker, 1) + "after a newline",
+		PayloadPart(marker, 2) + "\tleading and\ttrailing tabs\t.",
+	}
+
+	var p Payload
+	for _, line := range lines {
+		p.Messages = append(p.Messages, []byte(line))
+	}
+	p.Body = []byte(strings.Join(lines, "\n"))
+
+	return p
+}
+
+// UTF8Payload returns a line of multibyte characters and emoji, including
+// ones made of several code points. It is expected unchanged.
+func UTF8Payload(marker string) Payload {
+	line := []byte(PayloadPart(marker, 0) + "héllo wörld — 日本語 — Ωμέγα — 🚀🔥 👍🏽 👨‍👩‍👧 🇳🇱")
+
+	return Payload{Body: line, Messages: [][]byte{line}}
+}
+
+// InvalidUTF8Payload returns a line with invalid and truncated UTF-8
+// sequences. It is expected unchanged, byte for byte.
+func InvalidUTF8Payload(marker string) Payload {
+	line := []byte(PayloadPart(marker, 0) + "bad \xff\xfe start \xc3\x28 continuation \xe2\x82 truncated \xed\xa0\x80 surrogate end")
+
+	return Payload{Body: line, Messages: [][]byte{line}}
+}
+
+// EmitConstantLogger makes a constant-logger app write body verbatim.
+func EmitConstantLogger(appName string, body []byte) {
+	cfg := cli.Config()
+
+	client := &http.Client{Timeout: cfg.DefaultTimeout}
+	resp, err := client.Post(
+		fmt.Sprintf("http://%s.%s/emit", appName, cfg.CFDomain),
+		"application/octet-stream",
+		bytes.NewReader(body),
+	)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	defer resp.Body.Close()
+
+	ExpectWithOffset(1, resp.StatusCode).To(Equal(http.StatusOK), "Failed to emit payload")
+}
+
+// AwaitDrainDelivery emits probes from a constant-logger app until one of
+// them reaches the syslog drain listener.
+func AwaitDrainDelivery(appName, listenerAppName string) {
+	probe := generator.PrefixedRandomName("DRAIN-PROBE", "")
+
+	EventuallyWithOffset(1, func() [][]byte {
+		EmitConstantLogger(appName, []byte(probe))
+		return DrainedMessages(listenerAppName, probe)
+	}, cli.Config().DefaultTimeout+3*time.Minute, 5*time.Second).ShouldNot(BeEmpty(), "drain never delivered")
+}
+
+// DrainedMessages returns the exact bytes of the recent messages received by
+// a syslog drain listener that contain substr.
+func DrainedMessages(listenerAppName, substr string) [][]byte {
+	return drainedMessages(listenerAppName, "", substr, 0)
+}
+
+// DrainedMessagesAt is DrainedMessages for the drains whose URL has the
+// given path. An empty path matches all drains.
+func DrainedMessagesAt(listenerAppName, path, substr string) [][]byte {
+	return drainedMessages(listenerAppName, path, substr, 0)
+}
+
+// HasDrainedMessageAt reports whether a listener has a recent message that
+// contains substr at the given path, without fetching all of them.
+func HasDrainedMessageAt(listenerAppName, path, substr string) bool {
+	return len(drainedMessages(listenerAppName, path, substr, 1)) > 0
+}
+
+// DrainedCounts counts the recent messages at a listener that contain
+// substr by the submatches of group, joined by spaces. Only the counts are
+// fetched, so it suits listeners with many messages.
+func DrainedCounts(listenerAppName, path, substr, group string) map[string]int {
+	var counts map[string]int
+	listenerGet(2, listenerAppName, "/messages", url.Values{
+		"contains": {substr},
+		"path":     {path},
+		"group":    {group},
+	}, &counts)
+
+	return counts
+}
+
+// DrainEvictions returns how many messages drained to path a listener has
+// evicted to stay within its store's limit, for any path if path is empty.
+// Messages counted from the listener are only complete while it is 0.
+func DrainEvictions(listenerAppName, path string) int {
+	var evicted int
+	listenerGet(2, listenerAppName, "/evicted", url.Values{"path": {path}}, &evicted)
+
+	return evicted
+}
+
+func drainedMessages(listenerAppName, path, substr string, limit int) [][]byte {
+	var messages [][]byte
+	listenerGet(3, listenerAppName, "/messages", url.Values{
+		"contains": {substr},
+		"path":     {path},
+		"limit":    {strconv.Itoa(limit)},
+	}, &messages)
+
+	return messages
+}
+
+// listenerGet decodes the JSON response to a GET of path from a listener,
+// failing offset calls up the stack if it can't.
+func listenerGet(offset int, listenerAppName, path string, query url.Values, v interface{}) {
+	cfg := cli.Config()
+
+	client := &http.Client{
+		Timeout: cfg.DefaultTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.SkipCertVerify},
+		},
+	}
+	u := url.URL{
+		Scheme:   "https",
+		Host:     fmt.Sprintf("%s.%s", listenerAppName, cfg.CFDomain),
+		Path:     path,
+		RawQuery: query.Encode(),
+	}
+
+	resp, err := client.Get(u.String())
+	ExpectWithOffset(offset, err).ToNot(HaveOccurred())
+	defer resp.Body.Close()
+
+	ExpectWithOffset(offset, resp.StatusCode).To(Equal(http.StatusOK), "Failed to read from the listener")
+	ExpectWithOffset(offset, json.NewDecoder(resp.Body).Decode(v)).To(Succeed())
+}
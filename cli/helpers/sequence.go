@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var sequenceRegex = regexp.MustCompile(`\bseq=(\d+)\b`)
+
+// ParseSequence returns the sequence number of a constant-logger line, such
+// as "APP_LOG: <app> instance=0 seq=12 run=<id>", written with the given
+// label by appName. The line may carry any prefix, such as cf logs or syslog
+// headers.
+func ParseSequence(line, label, appName string) (uint64, bool) {
+	i := strings.Index(line, label+": "+appName+" ")
+	if i < 0 {
+		return 0, false
+	}
+
+	m := sequenceRegex.FindStringSubmatch(line[i:])
+	if m == nil {
+		return 0, false
+	}
+
+	seq, err := strconv.ParseUint(m[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return seq, true
+}
+
+var runRegex = regexp.MustCompile(`\brun=(\S+)`)
+
+// ParseRun returns the run ID a constant-logger line ends in. It is the
+// instance GUID of the container that logged the line, so it differs
+// between an instance and its restarts.
+func ParseRun(line string) (string, bool) {
+	m := runRegex.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+
+	return m[1], true
+}
+
+// MissingSequences returns the numbers in [0, n) that are not in seen.
+func MissingSequences(seen map[uint64]bool, n uint64) []uint64 {
+	var missing []uint64
+	for i := uint64(0); i < n; i++ {
+		if !seen[i] {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// DeliveryRatio returns the fraction of the numbers in [lo, hi] that are in
+// seen.
+func DeliveryRatio(seen map[uint64]bool, lo, hi uint64) float64 {
+	if hi < lo {
+		return 0
+	}
+
+	var delivered uint64
+	for seq := range seen {
+		if seq >= lo && seq <= hi {
+			delivered++
+		}
+	}
+
+	return float64(delivered) / float64(hi-lo+1)
+}
+
+// Disorder summarizes how far a list of sequence numbers, in arrival order,
+// is from ascending order.
+type Disorder struct {
+	// OutOfOrder counts the numbers that arrived after a greater one.
+	OutOfOrder int
+
+	// MaxDistance is the largest difference between such a number and the
+	// greatest number that arrived before it.
+	MaxDistance uint64
+}
+
+// MeasureDisorder compares every number in seqs to the greatest one before
+// it. Repeated numbers are not counted as out of order.
+func MeasureDisorder(seqs []uint64) Disorder {
+	var (
+		d    Disorder
+		max  uint64
+		seen bool
+	)
+
+	for _, seq := range seqs {
+		if seen && seq < max {
+			d.OutOfOrder++
+			if max-seq > d.MaxDistance {
+				d.MaxDistance = max - seq
+			}
+			continue
+		}
+
+		max, seen = seq, true
+	}
+
+	return d
+}
+
+func (d Disorder) String() string {
+	return fmt.Sprintf("%d out of order, max distance %d", d.OutOfOrder, d.MaxDistance)
+}
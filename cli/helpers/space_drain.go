@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"path"
+	"sync"
+
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+const spaceDrainPackage = "code.cloudfoundry.org/cf-drain-cli/cmd/space_drain"
+
+var (
+	spaceDrainOnce sync.Once
+	spaceDrainDir  string
+	spaceDrainErr  error
+)
+
+// SpaceDrainDir returns the directory holding a space_drain binary built for
+// the cells (linux/amd64). The binary is only built on the first call; it is
+// removed by CleanupSpaceDrain.
+func SpaceDrainDir() string {
+	spaceDrainOnce.Do(func() {
+		execPath, err := BuildWithEnvironment(spaceDrainPackage, []string{
+			"GOOS=linux",
+			"GOARCH=amd64",
+			"CGO_ENABLED=0",
+		})
+		spaceDrainDir, spaceDrainErr = path.Dir(execPath), err
+	})
+
+	ExpectWithOffset(1, spaceDrainErr).ToNot(HaveOccurred(), "Failed to build space_drain")
+
+	return spaceDrainDir
+}
+
+// CleanupSpaceDrain removes the space_drain binary. It should only be called
+// from AfterSuite.
+func CleanupSpaceDrain() {
+	CleanupBuildArtifacts()
+}
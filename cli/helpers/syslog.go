@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var syslogStartRegex = regexp.MustCompile(`<(\d{1,3})>1 `)
+
+// SyslogMessage is an RFC 5424 message as received by the drain listener.
+type SyslogMessage struct {
+	Priority  int
+	Timestamp string
+	Hostname  string
+	AppName   string
+	ProcID    string
+	MsgID     string
+
+	// StructuredData maps SD-IDs, such as "tags@47450", to their params.
+	StructuredData map[string]map[string]string
+
+	Message string
+}
+
+// Facility returns the facility encoded in the priority.
+func (m SyslogMessage) Facility() int {
+	return m.Priority / 8
+}
+
+// Severity returns the severity encoded in the priority.
+func (m SyslogMessage) Severity() int {
+	return m.Priority % 8
+}
+
+// ParseSyslog finds and parses the syslog message in a line of the drain
+// listener's output. Anything before the message, such as the listener's own
+// cf logs prefix, is ignored.
+func ParseSyslog(line string) (SyslogMessage, bool) {
+	loc := syslogStartRegex.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return SyslogMessage{}, false
+	}
+
+	pri, err := strconv.Atoi(line[loc[2]:loc[3]])
+	if err != nil {
+		return SyslogMessage{}, false
+	}
+
+	// TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	rest := line[loc[1]:]
+	header := make([]string, 5)
+	for i := range header {
+		j := strings.IndexByte(rest, ' ')
+		if j < 0 {
+			return SyslogMessage{}, false
+		}
+		header[i], rest = rest[:j], rest[j+1:]
+	}
+
+	sd, msg, ok := parseStructuredData(rest)
+	if !ok {
+		return SyslogMessage{}, false
+	}
+
+	return SyslogMessage{
+		Priority:       pri,
+		Timestamp:      header[0],
+		Hostname:       header[1],
+		AppName:        header[2],
+		ProcID:         header[3],
+		MsgID:          header[4],
+		StructuredData: sd,
+		Message:        msg,
+	}, true
+}
+
+// parseStructuredData parses the STRUCTURED-DATA part of a message and
+// returns it along with the rest of the message.
+func parseStructuredData(s string) (map[string]map[string]string, string, bool) {
+	sd := map[string]map[string]string{}
+
+	if strings.HasPrefix(s, "-") {
+		return sd, strings.TrimPrefix(strings.TrimPrefix(s, "-"), " "), true
+	}
+
+	for strings.HasPrefix(s, "[") {
+		end := strings.IndexAny(s, " ]")
+		if end < 0 {
+			return nil, "", false
+		}
+		id := s[1:end]
+		params := map[string]string{}
+		s = s[end:]
+
+		for strings.HasPrefix(s, " ") {
+			s = s[1:]
+
+			eq := strings.Index(s, `="`)
+			if eq < 0 {
+				return nil, "", false
+			}
+			name := s[:eq]
+			s = s[eq+2:]
+
+			var value strings.Builder
+			for {
+				if s == "" {
+					return nil, "", false
+				}
+				c := s[0]
+				s = s[1:]
+
+				if c == '\\' && s != "" && strings.IndexByte(`"\]`, s[0]) >= 0 {
+					value.WriteByte(s[0])
+					s = s[1:]
+					continue
+				}
+				if c == '"' {
+					break
+				}
+				value.WriteByte(c)
+			}
+			params[name] = value.String()
+		}
+
+		if !strings.HasPrefix(s, "]") {
+			return nil, "", false
+		}
+		s = s[1:]
+		sd[id] = params
+	}
+
+	return sd, strings.TrimPrefix(s, " "), true
+}
@@ -0,0 +1,132 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+// User is a non-admin user. Commands run as the user have their own
+// CF_HOME, so logging in as them leaves the admin's session and target
+// alone. Plugins are shared with the admin.
+type User struct {
+	Name string
+
+	password string
+	home     string
+	env      []string
+}
+
+// CreateUser creates a user with a random name and password and logs them
+// in. The user has no roles until they are given some by the admin.
+func CreateUser() *User {
+	cfg := cli.Config()
+
+	home, err := ioutil.TempDir("", "cf-user-home")
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	u := &User{
+		Name:     generator.PrefixedRandomName("CFDRAIN", "USER"),
+		password: generator.PrefixedRandomName("PASSWORD", ""),
+		home:     home,
+		env: []string{
+			"CF_HOME=" + home,
+			"CF_PLUGIN_HOME=" + pluginHome(),
+		},
+	}
+
+	s := u.run(nil, "create-user", u.Name, u.password)
+	EventuallyWithOffset(1, s, cfg.DefaultTimeout).Should(Exit(0), "Failed to create user")
+
+	api := []string{"api", "https://api." + cfg.CFDomain}
+	if cfg.SkipCertVerify {
+		api = append(api, "--skip-ssl-validation")
+	}
+	EventuallyWithOffset(1, u.Cf(api...), cfg.DefaultTimeout).Should(Exit(0), "Failed to set API for user")
+
+	s = u.run(u.env, "auth", u.Name, u.password)
+	EventuallyWithOffset(1, s, cfg.DefaultTimeout).Should(Exit(0), "Failed to log in as user")
+
+	return u
+}
+
+// Cf runs cf as the user.
+func (u *User) Cf(args ...string) *Session {
+	return u.run(u.env, args...)
+}
+
+// Target targets an org and space as the user.
+func (u *User) Target(org, space string) {
+	EventuallyWithOffset(1, u.Cf("target", "-o", org, "-s", space), cli.Config().DefaultTimeout).Should(Exit(0), "Failed to target as user")
+}
+
+// LogsTail runs `cf logs <app> --recent` as the user.
+func (u *User) LogsTail(appName string) *Session {
+	return u.Cf("logs", appName, "--recent")
+}
+
+// LogsFollowLines streams `cf logs <app>` as the user.
+func (u *User) LogsFollowLines(appName string) *LineStream {
+	return startLineStream(u.env, "logs", appName)
+}
+
+// LogStreamLines streams `cf log-stream <args>` as the user.
+func (u *User) LogStreamLines(args ...string) *LineStream {
+	return startLineStream(u.env, append([]string{"log-stream"}, args...)...)
+}
+
+// OAuthToken returns the user's token, including the "bearer" prefix. The
+// command is run directly so the token is never captured.
+func (u *User) OAuthToken() string {
+	out, err := cfCommand(u.env, "oauth-token").Output()
+	ExpectWithOffset(1, err).ToNot(HaveOccurred(), "Failed to get oauth token for user")
+
+	return strings.TrimSpace(string(out))
+}
+
+// LogCacheRead issues a single read for a source ID as the user and returns
+// the status code and body of the response whatever they are. Log Cache
+// authorizes reads as the log-stream plugin's gateway does, so a denied
+// read shows a stream is denied rather than merely slow.
+func (u *User) LogCacheRead(sourceID string) (int, []byte) {
+	status, body, err := logCacheClient().Do(u.OAuthToken(), "/api/v1/read/"+sourceID, nil)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	return status, body
+}
+
+// Delete deletes the user, as the admin, and removes their CF_HOME.
+func (u *User) Delete() {
+	u.run(nil, "delete-user", u.Name, "-f").Wait(cli.Config().DefaultTimeout)
+	os.RemoveAll(u.home)
+}
+
+// run starts cf with env, keeping the user's password out of the captured
+// command line.
+func (u *User) run(env []string, args ...string) *Session {
+	command := strings.Replace("cf "+strings.Join(args, " "), u.password, "[REDACTED]", -1)
+
+	s, err := startQuiet(cfCommand(env, args...), command)
+	ExpectWithOffset(2, err).ToNot(HaveOccurred())
+
+	return s
+}
+
+// pluginHome returns the directory cf looks for the admin's plugins in.
+func pluginHome() string {
+	for _, env := range []string{"CF_PLUGIN_HOME", "CF_HOME"} {
+		if dir := os.Getenv(env); dir != "" {
+			return dir
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	ExpectWithOffset(2, err).ToNot(HaveOccurred())
+
+	return home
+}
@@ -0,0 +1,148 @@
+package cli_test
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LogRateLimit", func() {
+
+	const (
+		// logRateLimit matches the "-l 4K" the app is pushed with.
+		logRateLimit = 4096
+		lineBytes    = 256
+
+		// log.Print prefixes every line with a 20 byte timestamp.
+		emittedLineBytes = lineBytes + 20
+
+		tolerance = 0.25
+		window    = 30 * time.Second
+	)
+
+	var (
+		appName   string
+		drainName string
+		streams   []*LineStream
+	)
+
+	BeforeEach(func() {
+		streams = nil
+		drainName = ""
+
+		appName = PushConstantLogger(map[string]string{
+			"BURST_BYTES_PER_SECOND": strconv.Itoa(4 * logRateLimit),
+			"LOG_LINE_BYTES":         strconv.Itoa(lineBytes),
+		}, "-l", "4K")
+	})
+
+	AfterEach(func() {
+		for _, s := range streams {
+			s.Kill()
+		}
+
+		if drainName != "" {
+			cf.Cf("delete-drain", drainName, "--force").Wait(cli.Config().DefaultTimeout)
+		}
+
+		cf.Cf("delete", appName, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+	})
+
+	It("reports that the app exceeded its log rate limit", func() {
+		logs := LogsFollowLines(appName)
+		streams = append(streams, logs)
+
+		_, err := logs.WaitFor(
+			Matching(`app instance exceeded log rate limit \(\d+ bytes/sec\)`),
+			cli.Config().DefaultTimeout,
+		)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("keeps the volume in cf logs --recent within the limit", func() {
+		time.Sleep(window)
+
+		s := LogsTail(appName).Wait(cli.Config().DefaultTimeout)
+		lines := strings.Split(string(s.Out.Contents()), "\n")
+
+		rate := recentBytesPerSecond(lines, "APP_LOG: "+appName, emittedLineBytes)
+		Expect(rate).To(BeNumerically("~", logRateLimit, tolerance*logRateLimit))
+	})
+
+	It("keeps the volume at the drain within the limit", func() {
+		syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
+		drainName = generator.PrefixedRandomName("LOG-RATE-LIMIT", "DRAIN")
+		CF("drain", appName, syslogDrainURL, "--drain-name", drainName)
+
+		logs := LogsFollowLines(listenerAppName)
+		streams = append(streams, logs)
+
+		var delivered int64
+		logs.OnLine(func(line string) {
+			if strings.Contains(line, "APP_LOG: "+appName) {
+				atomic.AddInt64(&delivered, emittedLineBytes)
+			}
+		})
+
+		_, err := logs.WaitFor(Containing("APP_LOG: "+appName), cli.Config().DefaultTimeout+3*time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+
+		atomic.StoreInt64(&delivered, 0)
+		time.Sleep(window)
+
+		rate := float64(atomic.LoadInt64(&delivered)) / window.Seconds()
+		Expect(rate).To(BeNumerically("~", logRateLimit, tolerance*logRateLimit))
+	})
+})
+
+var cfLogsTimestamp = regexp.MustCompile(`^\s*(\S+) \[`)
+
+// recentBytesPerSecond estimates the rate of lines containing marker from
+// the cf logs timestamps of the first and last of them.
+func recentBytesPerSecond(lines []string, marker string, lineBytes int) float64 {
+	var (
+		count       int
+		first, last time.Time
+	)
+
+	for _, line := range lines {
+		if !strings.Contains(line, marker) {
+			continue
+		}
+
+		m := cfLogsTimestamp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		t, err := time.Parse("2006-01-02T15:04:05.00-0700", m[1])
+		if err != nil {
+			continue
+		}
+
+		if count == 0 {
+			first = t
+		}
+		last = t
+		count++
+	}
+
+	span := last.Sub(first).Seconds()
+	if count < 2 || span <= 0 {
+		return 0
+	}
+
+	// The first line starts the span, so it is not counted.
+	return float64((count-1)*lineBytes) / span
+}
@@ -0,0 +1,227 @@
+package cli_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LogStreamFilters", func() {
+
+	const (
+		// window is how long a stream is watched for excluded envelopes
+		// after every wanted one has appeared.
+		window = 30 * time.Second
+
+		pollInterval = 5 * time.Second
+
+		// maxCrashes is how many crashes Diego restarts at once, before
+		// it backs off.
+		maxCrashes = 3
+	)
+
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		pingers  sync.WaitGroup
+		appA     string
+		appAGUID string
+		appB     string
+		appBGUID string
+		streams  []*LineStream
+	)
+
+	// ping requests the app's route every second until ctx is done, so the
+	// router emits timers for it.
+	ping := func(appName string) {
+		pingers.Add(1)
+		go func() {
+			defer pingers.Done()
+
+			client := &http.Client{Timeout: 5 * time.Second}
+			url := fmt.Sprintf("http://%s.%s/", appName, cli.Config().CFDomain)
+
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if resp, err := client.Get(url); err == nil {
+						resp.Body.Close()
+					}
+				}
+			}
+		}()
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		streams = nil
+
+		appA = PushConstantLogger(nil)
+		appAGUID = AppGUID(appA)
+		appB = PushConstantLogger(nil)
+		appBGUID = AppGUID(appB)
+
+		ping(appA)
+		ping(appB)
+	})
+
+	AfterEach(func() {
+		for _, s := range streams {
+			s.Kill()
+		}
+
+		cancel()
+		pingers.Wait()
+
+		cf.Cf("delete", appA, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		cf.Cf("delete", appB, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+	})
+
+	// resolve turns a source in an entry into the log-stream argument and
+	// the source ID of its envelopes. "appA" is passed by name, "appA-guid"
+	// and "appB-guid" by GUID, and anything else as is.
+	resolve := func(source string) (string, string) {
+		switch source {
+		case "appA":
+			return appA, appAGUID
+		case "appA-guid":
+			return appAGUID, appAGUID
+		case "appB-guid":
+			return appBGUID, appBGUID
+		}
+		return source, source
+	}
+
+	// Wanted envelopes are given as "<source>:<type>". No sources means
+	// every source, no types every type.
+	DescribeTable("only streams the requested sources and types",
+		func(sources, types []string, wanted ...string) {
+			cfg := cli.Config()
+
+			var args []string
+			allowedSources := map[string]bool{}
+			for _, source := range sources {
+				arg, sourceID := resolve(source)
+				args = append(args, arg)
+				allowedSources[sourceID] = true
+			}
+
+			allowedTypes := map[string]bool{}
+			for _, t := range types {
+				args = append(args, "--type", t)
+				allowedTypes[t] = true
+			}
+
+			var (
+				mu       sync.Mutex
+				excluded []string
+			)
+			check := func(line string) {
+				e, ok := ParseEnvelope(line)
+				if !ok {
+					return
+				}
+
+				if (len(sources) > 0 && !allowedSources[e.SourceID]) ||
+					(len(types) > 0 && !allowedTypes[e.Type()]) {
+					mu.Lock()
+					excluded = append(excluded, line)
+					mu.Unlock()
+				}
+			}
+
+			s := LogStreamLines(args...)
+			streams = append(streams, s)
+			s.OnLine(check)
+
+			for _, w := range wanted {
+				parts := strings.SplitN(w, ":", 2)
+				_, sourceID := resolve(parts[0])
+				envelopeType := parts[1]
+
+				match := func(line string) bool {
+					e, ok := ParseEnvelope(line)
+					return ok && e.SourceID == sourceID && e.Type() == envelopeType
+				}
+
+				if envelopeType == "event" {
+					// Apps emit events when they crash. The first crash may
+					// come before the stream is connected, so appA is
+					// crashed again while it runs, until an event arrives
+					// or it would be backed off.
+					crashes := 0
+					Eventually(func() error {
+						if crashes < maxCrashes && RunningInstances(appA) == 1 {
+							CrashConstantLogger(appA, 0)
+							crashes++
+						}
+						_, err := s.WaitFor(match, pollInterval)
+						return err
+					}, cfg.EnvelopeTypeTimeout, pollInterval).Should(Succeed(), "wanted %s", w)
+					continue
+				}
+
+				_, err := s.WaitFor(match, cfg.EnvelopeTypeTimeout)
+				Expect(err).ToNot(HaveOccurred(), "wanted %s", w)
+			}
+
+			time.Sleep(window)
+
+			// Lines from before the handler was registered are only in the
+			// recent ones.
+			for _, line := range s.Recent() {
+				check(line)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(excluded).To(BeEmpty(), "log-stream %s", strings.Join(args, " "))
+		},
+
+		Entry("log", []string{"appA-guid"}, []string{"log"},
+			"appA-guid:log"),
+		Entry("counter", []string{"doppler"}, []string{"counter"},
+			"doppler:counter"),
+		Entry("gauge", []string{"appA-guid"}, []string{"gauge"},
+			"appA-guid:gauge"),
+		Entry("timer", []string{"appA-guid"}, []string{"timer"},
+			"appA-guid:timer"),
+		Entry("event", []string{"appA-guid", "doppler"}, []string{"event"},
+			"appA-guid:event"),
+
+		Entry("log and gauge", []string{"appA-guid"}, []string{"log", "gauge"},
+			"appA-guid:log", "appA-guid:gauge"),
+		Entry("log and timer", []string{"appA-guid"}, []string{"log", "timer"},
+			"appA-guid:log", "appA-guid:timer"),
+		Entry("counter and gauge", []string{"doppler"}, []string{"counter", "gauge"},
+			"doppler:counter", "doppler:gauge"),
+		Entry("gauge and event", []string{"appA-guid"}, []string{"gauge", "event"},
+			"appA-guid:gauge", "appA-guid:event"),
+
+		Entry("several source IDs", []string{"appA-guid", "appB-guid", "doppler"}, nil,
+			"appA-guid:log", "appB-guid:log", "doppler:counter"),
+		Entry("several source IDs and a type", []string{"appA-guid", "doppler"}, []string{"gauge"},
+			"appA-guid:gauge", "doppler:gauge"),
+
+		Entry("an app name and a source ID", []string{"appA", "appB-guid"}, nil,
+			"appA:log", "appB-guid:log"),
+		Entry("an app name and a platform source ID with types", []string{"appA", "doppler"}, []string{"log", "counter"},
+			"appA:log", "doppler:counter"),
+	)
+})
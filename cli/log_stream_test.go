@@ -1,6 +1,7 @@
 package cli_test
 
 import (
+	"context"
 	"time"
 
 	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
@@ -16,12 +17,15 @@ import (
 var _ = Describe("LogStream", func() {
 
 	var (
-		interrupt chan struct{}
-		logs      *Session
+		ctx     context.Context
+		cancel  context.CancelFunc
+		writers []*LogWriter
+		logs    *Session
 	)
 
 	BeforeEach(func() {
-		interrupt = make(chan struct{}, 1)
+		ctx, cancel = context.WithCancel(context.Background())
+		writers = nil
 
 		cf.Cf("restart", logWriterAppName1).Wait(cli.Config().DefaultTimeout)
 	})
@@ -31,13 +35,16 @@ var _ = Describe("LogStream", func() {
 			logs.Kill()
 		}
 
-		close(interrupt)
+		cancel()
+		for _, w := range writers {
+			Expect(w.Wait()).To(Succeed())
+		}
 	})
 
 	It("prints logs", func() {
 		randomMessage := generator.PrefixedRandomName("RANDOM-MESSAGE-A", "LOG")
 
-		go WriteToLogsApp(interrupt, randomMessage, logWriterAppName1)
+		writers = append(writers, StartLogWriter(ctx, randomMessage, logWriterAppName1))
 
 		logs = LogStream()
 		Eventually(logs, cli.Config().DefaultTimeout+3*time.Minute).Should(Say(randomMessage))
@@ -46,7 +53,7 @@ var _ = Describe("LogStream", func() {
 	It("prints logs by app name", func() {
 		randomMessage := generator.PrefixedRandomName("RANDOM-MESSAGE-B", "LOG")
 
-		go WriteToLogsApp(interrupt, randomMessage, logWriterAppName1)
+		writers = append(writers, StartLogWriter(ctx, randomMessage, logWriterAppName1))
 
 		logs = LogStream(logWriterAppName1)
 		Eventually(logs, cli.Config().DefaultTimeout+3*time.Minute).Should(Say(randomMessage))
@@ -0,0 +1,138 @@
+package cli_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MultiInstance", func() {
+
+	const instances = 3
+
+	var (
+		ctx     context.Context
+		cancel  context.CancelFunc
+		writers []*LogWriter
+		streams []*LineStream
+		apps    []string
+		drains  []string
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		writers = nil
+		streams = nil
+		apps = nil
+		drains = nil
+	})
+
+	AfterEach(func() {
+		cancel()
+
+		for _, s := range streams {
+			s.Kill()
+		}
+
+		for _, drain := range drains {
+			cf.Cf("delete-drain", drain, "--force").Wait(cli.Config().DefaultTimeout)
+		}
+
+		for _, app := range apps {
+			cf.Cf("delete", app, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		}
+
+		for _, w := range writers {
+			Expect(w.Wait()).To(Succeed())
+		}
+	})
+
+	// writeToEachInstance starts a writer per instance and returns the
+	// message each instance logs, by index.
+	writeToEachInstance := func(appName string) []string {
+		guid := AppGUID(appName)
+
+		var messages []string
+		for i := 0; i < instances; i++ {
+			message := generator.PrefixedRandomName(fmt.Sprintf("RANDOM-MESSAGE-%d", i), "LOG")
+			messages = append(messages, message)
+			writers = append(writers, StartLogWriter(ctx, message, appName, WithAppInstance(guid, i)))
+		}
+
+		return messages
+	}
+
+	It("tags each instance's logs with its index in cf logs", func() {
+		appName := PushLogWriter()
+		apps = append(apps, appName)
+		ScaleApp(appName, instances)
+
+		logs := LogsFollowLines(appName)
+		streams = append(streams, logs)
+
+		for i, message := range writeToEachInstance(appName) {
+			_, err := logs.WaitFor(
+				Matching(fmt.Sprintf(`\[APP/PROC/WEB/%d\]\s+OUT %s`, i, regexp.QuoteMeta(message))),
+				cli.Config().DefaultTimeout+3*time.Minute,
+			)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+
+	It("sets each instance's index as the PROCID at the drain", func() {
+		appName := PushLogWriter()
+		apps = append(apps, appName)
+		ScaleApp(appName, instances)
+
+		syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
+		drainName := generator.PrefixedRandomName("MULTI-INSTANCE", "DRAIN")
+		drains = append(drains, drainName)
+		CF("drain", appName, syslogDrainURL, "--drain-name", drainName)
+
+		logs := LogsFollowLines(listenerAppName)
+		streams = append(streams, logs)
+
+		for i, message := range writeToEachInstance(appName) {
+			_, err := logs.WaitFor(
+				Matching(fmt.Sprintf(`\[APP/PROC/WEB/%d\] - .*%s`, i, regexp.QuoteMeta(message))),
+				cli.Config().DefaultTimeout+3*time.Minute,
+			)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+
+	It("keeps the final logs of instances stopped by scaling down", func() {
+		appName := PushConstantLogger(nil, "-i", fmt.Sprint(instances))
+		apps = append(apps, appName)
+
+		logs := LogsFollowLines(appName)
+		streams = append(streams, logs)
+
+		for i := 0; i < instances; i++ {
+			_, err := logs.WaitFor(
+				Matching(fmt.Sprintf(`\[APP/PROC/WEB/%d\].*APP_LOG: %s instance=%d `, i, appName, i)),
+				cli.Config().DefaultTimeout,
+			)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		ScaleApp(appName, 1)
+
+		for i := 1; i < instances; i++ {
+			_, err := logs.WaitFor(
+				Matching(fmt.Sprintf(`\[APP/PROC/WEB/%d\].*SHUTDOWN: %s instance=%d .*remaining=0`, i, appName, i)),
+				cli.Config().DefaultTimeout,
+			)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+})
@@ -0,0 +1,195 @@
+package cli_test
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NoisyNeighbor", func() {
+
+	const (
+		// The noisy app logs 200KiB/s per instance, the quiet one five lines
+		// a second. More noisy instances make sharing a cell likelier.
+		noisyInstances      = 3
+		noisyBytesPerSecond = 200 << 10
+		noisyLineBytes      = 256
+		quietInterval       = 200 * time.Millisecond
+
+		window = time.Minute
+	)
+
+	var (
+		quietApp  string
+		noisyApp  string
+		listener  string
+		drainName string
+		drainPath string
+	)
+
+	// colocated tells whether a noisy instance runs on the quiet app's cell.
+	colocated := func() bool {
+		quietHosts := InstanceHosts(quietApp)
+		for _, host := range InstanceHosts(noisyApp) {
+			for _, quietHost := range quietHosts {
+				if host == quietHost {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	BeforeEach(func() {
+		listener = ""
+		drainName = ""
+
+		quietApp = PushConstantLogger(map[string]string{
+			"LOG_INTERVAL": quietInterval.String(),
+		})
+		noisyApp = PushConstantLogger(map[string]string{
+			"BURST_BYTES_PER_SECOND": strconv.Itoa(noisyBytesPerSecond),
+			"LOG_LINE_BYTES":         strconv.Itoa(noisyLineBytes),
+		}, "-i", strconv.Itoa(noisyInstances))
+
+		Eventually(func() int {
+			return RunningInstances(noisyApp)
+		}, cli.Config().AppPushTimeout, 2*time.Second).Should(Equal(noisyInstances))
+
+		// A restarted instance is placed again, and Diego prefers cells
+		// without an instance of the same app, such as the quiet app's.
+		restarts := cli.Config().NoisyNeighborPlacementRestarts
+		for i := 0; i < restarts && !colocated(); i++ {
+			CFWithTimeout(cli.Config().AppPushTimeout, "restart-app-instance", noisyApp, strconv.Itoa(i%noisyInstances))
+
+			Eventually(func() int {
+				return RunningInstances(noisyApp)
+			}, cli.Config().AppPushTimeout, 2*time.Second).Should(Equal(noisyInstances))
+		}
+		Expect(colocated()).To(BeTrue(), "no noisy instance was placed on the quiet app's cell after %d restarts", restarts)
+
+		// Both apps share one drain to a listener of their own, so the
+		// noise can't evict other specs' messages or the quiet app's. It
+		// neither prints nor stores the noisy lines, only the quiet app's
+		// and the noisy app's probes.
+		probe := generator.PrefixedRandomName("NOISY-NEIGHBOR", "PROBE")
+		listener = PushSyslogServerWithEnv(map[string]string{
+			"STORE_MATCH":    regexp.QuoteMeta("APP_LOG: "+quietApp+" ") + "|" + regexp.QuoteMeta(probe),
+			"PRINT_MESSAGES": "false",
+		}, 64)
+
+		drainPath = "/" + strings.ToLower(generator.PrefixedRandomName("noisy-neighbor", ""))
+		drainName = generator.PrefixedRandomName("NOISY-NEIGHBOR", "DRAIN")
+		CF(
+			"drain",
+			quietApp,
+			fmt.Sprintf("https://%s.%s%s", listener, cli.Config().CFDomain, drainPath),
+			"--drain-name", drainName,
+		)
+		CF("bind-drain", noisyApp, drainName)
+
+		Eventually(func() bool {
+			return HasDrainedMessageAt(listener, drainPath, "APP_LOG: "+quietApp+" ")
+		}, cli.Config().DrainReconcileTimeout, 5*time.Second).Should(BeTrue(), "%s never reached the drain", quietApp)
+
+		Eventually(func() bool {
+			EmitConstantLogger(noisyApp, []byte(probe))
+			return HasDrainedMessageAt(listener, drainPath, probe)
+		}, cli.Config().DrainReconcileTimeout, 5*time.Second).Should(BeTrue(), "%s never reached the drain", noisyApp)
+	})
+
+	AfterEach(func() {
+		if drainName != "" {
+			cf.Cf("delete-drain", drainName, "--force").Wait(cli.Config().DefaultTimeout)
+		}
+
+		cf.Cf("delete", noisyApp, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		cf.Cf("delete", quietApp, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+
+		if listener != "" {
+			cf.Cf("delete", listener, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		}
+	})
+
+	// quietSequences adds the quiet app's sequence numbers in lines to
+	// seen.
+	quietSequences := func(seen map[uint64]bool, lines []string) {
+		for _, line := range lines {
+			if seq, ok := ParseSequence(line, "APP_LOG", quietApp); ok {
+				seen[seq] = true
+			}
+		}
+	}
+
+	It("keeps delivering a quiet app's logs next to a noisy one", func() {
+		minDelivery := cli.Config().NoisyNeighborMinDelivery
+
+		atDrain := map[uint64]bool{}
+		pollDrain := func() {
+			var lines []string
+			for _, msg := range DrainedMessagesAt(listener, drainPath, "APP_LOG: "+quietApp+" ") {
+				lines = append(lines, string(msg))
+			}
+			quietSequences(atDrain, lines)
+		}
+
+		maxSeq := func(seen map[uint64]bool) uint64 {
+			var max uint64
+			for seq := range seen {
+				if seq > max {
+					max = seq
+				}
+			}
+			return max
+		}
+
+		pollDrain()
+		lo := maxSeq(atDrain) + 1
+
+		for end := time.Now().Add(window); time.Now().Before(end); {
+			time.Sleep(5 * time.Second)
+			pollDrain()
+		}
+
+		// Late lines may still be on their way.
+		time.Sleep(10 * time.Second)
+		pollDrain()
+
+		inRecent := map[uint64]bool{}
+		s := LogsTail(quietApp).Wait(cli.Config().DefaultTimeout)
+		quietSequences(inRecent, strings.Split(string(s.Out.Contents()), "\n"))
+
+		// The window ends at the last line seen anywhere, so lines logged
+		// after the measurement don't count as lost.
+		hi := maxSeq(atDrain)
+		if recentMax := maxSeq(inRecent); recentMax > hi {
+			hi = recentMax
+		}
+		Expect(hi).To(BeNumerically(">", lo), "no quiet lines during the window")
+
+		// Lines evicted by the listener would count as lost.
+		Expect(DrainEvictions(listener, drainPath)).To(BeZero(), "the listener evicted quiet lines")
+
+		for _, path := range []struct {
+			name string
+			seen map[uint64]bool
+		}{
+			{"drain", atDrain},
+			{"cf logs --recent", inRecent},
+		} {
+			ratio := DeliveryRatio(path.seen, lo, hi)
+			fmt.Fprintf(GinkgoWriter, "%s: %.4f of seq %d-%d delivered\n", path.name, ratio, lo, hi)
+			Expect(ratio).To(BeNumerically(">=", minDelivery), path.name)
+		}
+	})
+})
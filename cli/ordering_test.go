@@ -0,0 +1,145 @@
+package cli_test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ordering", func() {
+
+	// burstLines is half of the 1000 lines cf logs --recent shows, so a
+	// failure can still be checked against the recent logs by hand, with
+	// room left for router, API and cell lines.
+	const burstLines = 500
+
+	var (
+		appName   string
+		drainName string
+	)
+
+	BeforeEach(func() {
+		drainName = ""
+
+		// LOG_COUNT keeps the app's own lines out of the burst.
+		appName = PushConstantLogger(map[string]string{"LOG_COUNT": "1"})
+	})
+
+	AfterEach(func() {
+		if drainName != "" {
+			cf.Cf("delete-drain", drainName, "--force").Wait(cli.Config().DefaultTimeout)
+		}
+
+		cf.Cf("delete", appName, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+	})
+
+	// collect polls for the burst's sequence numbers, in arrival order,
+	// until all of them are there or the timeout expires. Lost lines are
+	// reported but are not what this spec checks.
+	collect := func(where string, read func() []string) []uint64 {
+		var seqs []uint64
+
+		deadline := time.Now().Add(cli.Config().DefaultTimeout)
+		for {
+			seqs = nil
+			seen := map[uint64]bool{}
+			for _, line := range read() {
+				if seq, ok := ParseSequence(line, "BURST_LOG", appName); ok {
+					seqs = append(seqs, seq)
+					seen[seq] = true
+				}
+			}
+
+			missing := MissingSequences(seen, burstLines)
+			if len(missing) == 0 || time.Now().After(deadline) {
+				fmt.Fprintf(GinkgoWriter, "%s: received %d of %d lines\n", where, burstLines-len(missing), burstLines)
+				break
+			}
+
+			time.Sleep(5 * time.Second)
+		}
+
+		Expect(seqs).ToNot(BeEmpty(), where)
+		return seqs
+	}
+
+	expectInOrder := func(where string, seqs []uint64) {
+		d := MeasureDisorder(seqs)
+		fmt.Fprintf(GinkgoWriter, "%s: %s\n", where, d)
+
+		Expect(d.OutOfOrder).To(BeNumerically("<=", cli.Config().MaxOutOfOrder), "%s: %s", where, d)
+		Expect(d.MaxDistance).To(BeNumerically("<=", cli.Config().MaxOutOfOrderDistance), "%s: %s", where, d)
+	}
+
+	It("delivers a burst from one instance in order at the drain", func() {
+		syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
+		drainName = generator.PrefixedRandomName("ORDERING", "DRAIN")
+		CF("drain", appName, syslogDrainURL, "--drain-name", drainName)
+		AwaitDrainDelivery(appName, listenerAppName)
+
+		CommandConstantLogger(appName, 0, "/burst/"+strconv.Itoa(burstLines))
+
+		// The listener keeps messages in the order they arrived.
+		seqs := collect("drain", func() []string {
+			var lines []string
+			for _, msg := range DrainedMessages(listenerAppName, "BURST_LOG: "+appName+" ") {
+				lines = append(lines, string(msg))
+			}
+			return lines
+		})
+		expectInOrder("drain", seqs)
+	})
+
+	// cf logs --recent sorts lines by timestamp, so only the stream shows
+	// the order they were delivered in. The recent logs show whether the
+	// timestamps follow the order the lines were logged in.
+	It("streams and returns a burst from one instance in order in cf logs", func() {
+		logs := LogsFollowLines(appName)
+		defer logs.Kill()
+
+		var (
+			mu    sync.Mutex
+			burst []string
+		)
+		logs.OnLine(func(line string) {
+			if strings.Contains(line, "BURST_LOG: "+appName+" ") {
+				mu.Lock()
+				burst = append(burst, line)
+				mu.Unlock()
+			}
+		})
+
+		// The burst must not start before the stream is connected.
+		probe := generator.PrefixedRandomName("ORDERING-PROBE", "")
+		Eventually(func() error {
+			EmitConstantLogger(appName, []byte(probe))
+			_, err := logs.WaitFor(Containing(probe), 5*time.Second)
+			return err
+		}, cli.Config().DefaultTimeout, time.Second).Should(Succeed(), "cf logs never connected")
+
+		CommandConstantLogger(appName, 0, "/burst/"+strconv.Itoa(burstLines))
+
+		seqs := collect("cf logs", func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string(nil), burst...)
+		})
+		recent := collect("cf logs --recent", func() []string {
+			s := LogsTail(appName).Wait(cli.Config().DefaultTimeout)
+			return strings.Split(string(s.Out.Contents()), "\n")
+		})
+
+		expectInOrder("cf logs", seqs)
+		expectInOrder("cf logs --recent", recent)
+	})
+})
@@ -0,0 +1,114 @@
+package cli_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Payloads", func() {
+
+	var (
+		appName   string
+		drainName string
+		appLogs   *LineStream
+		logStream *LineStream
+	)
+
+	BeforeEach(func() {
+		// LOG_COUNT keeps the app's own lines from interleaving with the
+		// emitted payloads, which are written to stdout.
+		appName = PushConstantLogger(map[string]string{
+			"LOG_COUNT":  "1",
+			"LOG_STREAM": "stdout",
+		})
+
+		syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
+		drainName = generator.PrefixedRandomName("PAYLOAD", "DRAIN")
+		CF("drain", appName, syslogDrainURL, "--drain-name", drainName)
+
+		appLogs = LogsFollowLines(appName)
+		logStream = LogStreamLines(appName)
+
+		AwaitDrainDelivery(appName, listenerAppName)
+	})
+
+	AfterEach(func() {
+		appLogs.Kill()
+		logStream.Kill()
+
+		cf.Cf("delete-drain", drainName, "--force").Wait(cli.Config().DefaultTimeout)
+		cf.Cf("delete", appName, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+	})
+
+	// Lines are compared as strings so failures show the text, but the
+	// comparison is still byte for byte.
+	DescribeTable("delivers payloads byte for byte, split where documented",
+		func(build func(marker string, maxLine int) Payload) {
+			marker := generator.PrefixedRandomName("PAYLOAD", "")
+			p := build(marker, cli.Config().MaxLogLineBytes)
+
+			EmitConstantLogger(appName, p.Body)
+
+			timeout := cli.Config().DefaultTimeout
+			for i, want := range p.Messages {
+				part := PayloadPart(marker, i)
+
+				line, err := appLogs.WaitFor(Containing(part), timeout)
+				Expect(err).ToNot(HaveOccurred(), "cf logs, part %d", i)
+				const label = "] OUT "
+				Expect(line).To(ContainSubstring(label))
+				Expect(line[strings.Index(line, label)+len(label):]).To(Equal(string(want)), "cf logs, part %d", i)
+
+				line, err = logStream.WaitFor(func(line string) bool {
+					e, ok := ParseEnvelope(line)
+					return ok && e.Log != nil && strings.Contains(string(e.Log.Payload), part)
+				}, timeout)
+				Expect(err).ToNot(HaveOccurred(), "log-stream, part %d", i)
+				e, _ := ParseEnvelope(line)
+				Expect(string(e.Log.Payload)).To(Equal(string(want)), "log-stream, part %d", i)
+
+				var drained [][]byte
+				Eventually(func() [][]byte {
+					drained = DrainedMessages(listenerAppName, part)
+					return drained
+				}, timeout, 2*time.Second).Should(HaveLen(1), "drain, part %d", i)
+
+				m, ok := ParseSyslog(string(drained[0]))
+				Expect(ok).To(BeTrue(), "unparseable drained message: %q", drained[0])
+				// The syslog agent ends every message with a newline.
+				Expect(strings.TrimSuffix(m.Message, "\n")).To(Equal(string(want)), "drain, part %d", i)
+			}
+
+			Expect(DrainedMessages(listenerAppName, marker)).To(HaveLen(len(p.Messages)), "drain")
+		},
+		Entry("a line at the size limit", func(marker string, maxLine int) Payload {
+			return LargePayload(marker, maxLine, maxLine)
+		}),
+		Entry("a line just over the size limit", func(marker string, maxLine int) Payload {
+			return LargePayload(marker, maxLine+100, maxLine)
+		}),
+		Entry("a line several times the size limit", func(marker string, maxLine int) Payload {
+			return LargePayload(marker, 3*maxLine+100, maxLine)
+		}),
+		Entry("a line split inside a multibyte character", RuneSplitPayload),
+		Entry("lines with embedded newlines and tabs", func(marker string, _ int) Payload {
+			return MultilinePayload(marker)
+		}),
+		Entry("multibyte UTF-8 and emoji", func(marker string, _ int) Payload {
+			return UTF8Payload(marker)
+		}),
+		Entry("invalid UTF-8 byte sequences", func(marker string, _ int) Payload {
+			return InvalidUTF8Payload(marker)
+		}),
+	)
+})
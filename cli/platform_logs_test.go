@@ -0,0 +1,109 @@
+package cli_test
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PlatformLogs", func() {
+
+	var apps []string
+
+	// recentLogs returns a function for Eventually that reads the app's
+	// recent logs.
+	recentLogs := func(appName string) func() string {
+		return func() string {
+			s := LogsTail(appName).Wait(cli.Config().DefaultTimeout)
+			return string(s.Out.Contents())
+		}
+	}
+
+	BeforeEach(func() {
+		apps = nil
+	})
+
+	AfterEach(func() {
+		for _, app := range apps {
+			cf.Cf("delete", app, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		}
+	})
+
+	It("includes buildpack output from staging", func() {
+		appName := PushLogWriter()
+		apps = append(apps, appName)
+
+		Eventually(recentLogs(appName), cli.Config().DefaultTimeout).Should(
+			MatchRegexp(`\[STG/\d+\]\s+OUT -----> `),
+		)
+	})
+
+	It("includes API and CELL events when the app is restarted", func() {
+		appName := PushLogWriter()
+		apps = append(apps, appName)
+		guid := AppGUID(appName)
+
+		CFWithTimeout(cli.Config().AppPushTimeout, "restart", appName)
+
+		Eventually(recentLogs(appName), cli.Config().DefaultTimeout).Should(And(
+			MatchRegexp(`\[API/\d+\]\s+OUT (Updated|Restarted) app with guid %s`, guid),
+			MatchRegexp(`\[CELL/\d+\]\s+OUT .*Container became healthy`),
+		))
+	})
+
+	It("includes CELL and API events when the app crashes", func() {
+		appName := PushConstantLogger(nil)
+		apps = append(apps, appName)
+
+		CrashConstantLogger(appName, 0)
+
+		Eventually(recentLogs(appName), cli.Config().DefaultTimeout).Should(And(
+			MatchRegexp(`\[APP/PROC/WEB/0\]\s+ERR .*CRASHING: %s instance=0`, appName),
+			MatchRegexp(`\[CELL/0\]\s+OUT .*[Ss]topping instance`),
+			MatchRegexp(`\[API/\d+\]\s+OUT Process has crashed with type: "web"`),
+		))
+	})
+
+	It("includes RTR access logs with the request's X-Vcap-Request-Id", func() {
+		appName := PushLogWriter()
+		apps = append(apps, appName)
+
+		message := generator.PrefixedRandomName("RANDOM-MESSAGE-A", "LOG")
+		sentID := generator.PrefixedRandomName("REQUEST-ID", "")
+
+		req, err := http.NewRequest(
+			http.MethodGet,
+			fmt.Sprintf("http://%s.%s/log/%s", appName, cli.Config().CFDomain, message),
+			nil,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("X-Vcap-Request-Id", sentID)
+
+		resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		// The router may replace the ID, the app echoes the one it received.
+		requestID := resp.Header.Get("X-Vcap-Request-Id")
+		Expect(requestID).ToNot(BeEmpty())
+
+		var accessLogs map[string]AccessLog
+		Eventually(func() map[string]AccessLog {
+			accessLogs = AccessLogsByRequestID(recentLogs(appName)())
+			return accessLogs
+		}, cli.Config().DefaultTimeout).Should(HaveKey(requestID))
+
+		Expect(accessLogs[requestID].Method).To(Equal(http.MethodGet))
+		Expect(accessLogs[requestID].Path).To(Equal("/log/" + message))
+		Expect(accessLogs[requestID].StatusCode).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,72 @@
+package cli_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RouterLogs", func() {
+
+	const requests = 5
+
+	It("emits an RTR access log for every request", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		message := generator.PrefixedRandomName("RANDOM-MESSAGE-A", "LOG")
+		writer := StartLogWriter(ctx, message, logWriterAppName1, WithInterval(time.Second))
+
+		// An empty message doesn't match the app's /log/:message route, so
+		// the app answers every request with a 404.
+		failing := StartLogWriter(ctx, "", logWriterAppName1, WithInterval(time.Second), WithRetries(0))
+
+		Eventually(writer.Succeeded, cli.Config().DefaultTimeout).Should(BeNumerically(">=", requests))
+		Eventually(failing.Failed, cli.Config().DefaultTimeout).Should(BeNumerically(">=", requests))
+		cancel()
+		Expect(writer.Wait()).To(Succeed())
+		Expect(failing.Wait()).To(HaveOccurred())
+
+		failed := failing.Requests()
+		Expect(len(failed)).To(BeNumerically(">=", requests))
+		for _, r := range failed {
+			Expect(r.StatusCode).To(Equal(http.StatusNotFound))
+		}
+
+		sent := append(writer.Requests(), failed...)
+		for _, r := range sent {
+			Expect(r.ID).ToNot(BeEmpty(), "%s %s returned %d without a request ID", r.Method, r.Path, r.StatusCode)
+		}
+
+		var accessLogs map[string]AccessLog
+		Eventually(func() []string {
+			s := LogsTail(logWriterAppName1).Wait(cli.Config().DefaultTimeout)
+			accessLogs = AccessLogsByRequestID(string(s.Out.Contents()))
+
+			var missing []string
+			for _, r := range sent {
+				if _, ok := accessLogs[r.ID]; !ok {
+					missing = append(missing, r.ID)
+				}
+			}
+			return missing
+		}, cli.Config().DefaultTimeout, 5*time.Second).Should(BeEmpty(), "requests without an RTR log")
+
+		for _, r := range sent {
+			l := accessLogs[r.ID]
+
+			Expect(l.Method).To(Equal(r.Method))
+			Expect(l.Path).To(Equal(r.Path))
+			Expect(l.StatusCode).To(Equal(r.StatusCode))
+			Expect(l.ResponseTime).To(BeNumerically(">", 0))
+			Expect(l.ResponseTime).To(BeNumerically("<=", r.Duration.Seconds()))
+		}
+	})
+})
@@ -1,8 +1,8 @@
 package cli_test
 
 import (
+	"context"
 	"fmt"
-	"path"
 	"regexp"
 	"strings"
 	"sync"
@@ -22,14 +22,17 @@ import (
 var _ = Describe("ServiceDrain", func() {
 
 	var (
-		interrupt   chan struct{}
+		ctx         context.Context
+		cancel      context.CancelFunc
+		writers     []*LogWriter
 		logs        *Session
 		drains      *Session
 		drainsRegex = `LOG-EMITTER-1--[0-9a-f]{16}\s+some-drain-[0-9a-f]{19}\s+Logs\s+https://.+`
 	)
 
 	BeforeEach(func() {
-		interrupt = make(chan struct{}, 1)
+		ctx, cancel = context.WithCancel(context.Background())
+		writers = nil
 	})
 
 	AfterEach(func() {
@@ -40,7 +43,7 @@ var _ = Describe("ServiceDrain", func() {
 			drains.Kill()
 		}
 
-		close(interrupt)
+		cancel()
 
 		var wg sync.WaitGroup
 		defer wg.Wait()
@@ -61,6 +64,10 @@ var _ = Describe("ServiceDrain", func() {
 			defer GinkgoRecover()
 			cf.Cf("restart", logWriterAppName2).Wait(cli.Config().DefaultTimeout)
 		}()
+
+		for _, w := range writers {
+			Expect(w.Wait()).To(Succeed())
+		}
 	})
 
 	It("drains an app's logs to syslog endpoint", func() {
@@ -77,8 +84,8 @@ var _ = Describe("ServiceDrain", func() {
 
 		logs = LogsFollow(listenerAppName)
 
-		go WriteToLogsApp(interrupt, randomMessage1, logWriterAppName1)
-		go WriteToLogsApp(interrupt, randomMessage2, logWriterAppName2)
+		writers = append(writers, StartLogWriter(ctx, randomMessage1, logWriterAppName1))
+		writers = append(writers, StartLogWriter(ctx, randomMessage2, logWriterAppName2))
 
 		Eventually(logs, cli.Config().DefaultTimeout+3*time.Minute).Should(Say(randomMessage1))
 		Consistently(logs, 10).ShouldNot(Say(randomMessage2))
@@ -106,8 +113,8 @@ var _ = Describe("ServiceDrain", func() {
 
 		logs = LogsFollow(listenerAppName)
 
-		go WriteToLogsApp(interrupt, randomMessage1, logWriterAppName1)
-		go WriteToLogsApp(interrupt, randomMessage2, logWriterAppName2)
+		writers = append(writers, StartLogWriter(ctx, randomMessage1, logWriterAppName1))
+		writers = append(writers, StartLogWriter(ctx, randomMessage2, logWriterAppName2))
 
 		Eventually(logs, cli.Config().DefaultTimeout+3*time.Minute).Should(Say(randomMessage1))
 		Eventually(logs, cli.Config().DefaultTimeout+3*time.Minute).Should(Say(randomMessage2))
@@ -117,17 +124,12 @@ var _ = Describe("ServiceDrain", func() {
 		syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
 		drainName := fmt.Sprintf("some-drain-%d", time.Now().UnixNano())
 
-		execPath, err := Build("code.cloudfoundry.org/cf-drain-cli/cmd/space_drain")
-		Expect(err).ToNot(HaveOccurred())
-
-		defer CleanupBuildArtifacts()
-
 		CFWithTimeout(
 			1*time.Minute,
 			"drain-space",
 			syslogDrainURL,
 			"--drain-name", drainName,
-			"--path", path.Dir(execPath),
+			"--path", SpaceDrainDir(),
 		)
 
 		defer CF("delete", drainName, "-f", "-r")
@@ -137,8 +139,8 @@ var _ = Describe("ServiceDrain", func() {
 
 		logs = LogsFollow(listenerAppName)
 
-		go WriteToLogsApp(interrupt, randomMessage1, logWriterAppName1)
-		go WriteToLogsApp(interrupt, randomMessage2, logWriterAppName2)
+		writers = append(writers, StartLogWriter(ctx, randomMessage1, logWriterAppName1))
+		writers = append(writers, StartLogWriter(ctx, randomMessage2, logWriterAppName2))
 
 		Eventually(logs, cli.Config().DefaultTimeout+3*time.Minute).Should(Say(randomMessage1))
 		Eventually(logs, cli.Config().DefaultTimeout+3*time.Minute).Should(Say(randomMessage2))
@@ -165,21 +167,12 @@ var _ = Describe("ServiceDrain", func() {
 		drainName := fmt.Sprintf("some-drain-%d", time.Now().UnixNano())
 		singleDrainName := fmt.Sprintf("single-some-drain-%d", time.Now().UnixNano())
 
-		execPath, err := BuildWithEnvironment("code.cloudfoundry.org/cf-drain-cli/cmd/space_drain", []string{
-			"GOOS=linux",
-			"GOARCH=amd64",
-		})
-
-		Expect(err).ToNot(HaveOccurred())
-
-		defer CleanupBuildArtifacts()
-
 		CFWithTimeout(
 			1*time.Minute,
 			"drain-space",
 			syslogDrainURL,
 			"--drain-name", drainName,
-			"--path", path.Dir(execPath),
+			"--path", SpaceDrainDir(),
 		)
 
 		CF(
@@ -265,24 +258,19 @@ var _ = Describe("ServiceDrain", func() {
 		syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
 		drainName := fmt.Sprintf("some-drain-%d", time.Now().UnixNano())
 
-		execPath, err := Build("code.cloudfoundry.org/cf-drain-cli/cmd/space_drain")
-		Expect(err).ToNot(HaveOccurred())
-
-		defer CleanupBuildArtifacts()
-
 		CFWithTimeout(
 			1*time.Minute,
 			"drain-space",
 			syslogDrainURL,
 			"--drain-name", drainName,
-			"--path", path.Dir(execPath),
+			"--path", SpaceDrainDir(),
 		)
 
 		drainSpace := cf.Cf(
 			"drain-space",
 			syslogDrainURL,
 			"--drain-name", drainName,
-			"--path", path.Dir(execPath),
+			"--path", SpaceDrainDir(),
 		)
 
 		Eventually(drainSpace, cli.Config().DefaultTimeout).Should(Say("A drain with that name already exists. Use --drain-name to create a drain with a different name."))
@@ -294,17 +282,12 @@ var _ = Describe("ServiceDrain", func() {
 		syslogDrainURL1 := "syslog://space-drain-1.papertrail.com"
 		syslogDrainURL2 := "syslog://space-drain-2.splunk.com"
 
-		execPath, err := Build("code.cloudfoundry.org/cf-drain-cli/cmd/space_drain")
-		Expect(err).ToNot(HaveOccurred())
-
-		defer CleanupBuildArtifacts()
-
 		CFWithTimeout(
 			1*time.Minute,
 			"drain-space",
 			syslogDrainURL1,
 			"--drain-name", papertrailDrainName,
-			"--path", path.Dir(execPath),
+			"--path", SpaceDrainDir(),
 		)
 
 		CFWithTimeout(
@@ -312,7 +295,7 @@ var _ = Describe("ServiceDrain", func() {
 			"drain-space",
 			syslogDrainURL2,
 			"--drain-name", splunkDrainName,
-			"--path", path.Dir(execPath),
+			"--path", SpaceDrainDir(),
 		)
 
 		papertrailDrainRegex := fmt.Sprintf(`(?m:^%s)`, papertrailDrainName)
@@ -0,0 +1,131 @@
+package cli_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("SpaceDrainLifecycle", func() {
+
+	var (
+		ctx       context.Context
+		cancel    context.CancelFunc
+		writers   []*LogWriter
+		logs      *Session
+		drainName string
+		apps      []string
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		writers = nil
+		logs = nil
+		apps = nil
+
+		syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
+		drainName = fmt.Sprintf("some-drain-%d", time.Now().UnixNano())
+
+		CFWithTimeout(
+			1*time.Minute,
+			"drain-space",
+			syslogDrainURL,
+			"--drain-name", drainName,
+			"--path", SpaceDrainDir(),
+		)
+	})
+
+	AfterEach(func() {
+		if logs != nil {
+			logs.Kill()
+		}
+
+		cancel()
+
+		CFWithTimeout(
+			1*time.Minute,
+			"delete-drain-space",
+			drainName,
+			"--force",
+		)
+
+		for _, app := range apps {
+			cf.Cf("delete", app, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		}
+
+		for _, w := range writers {
+			Expect(w.Wait()).To(Succeed())
+		}
+	})
+
+	It("binds apps pushed after the space drain is created", func() {
+		appName := PushLogWriter()
+		apps = append(apps, appName)
+
+		Eventually(func() bool {
+			return HasDrain(appName, drainName)
+		}, cli.Config().DrainReconcileTimeout, 5*time.Second).Should(BeTrue())
+
+		randomMessage := generator.PrefixedRandomName("RANDOM-MESSAGE-A", "LOG")
+
+		logs = LogsFollow(listenerAppName)
+
+		writers = append(writers, StartLogWriter(ctx, randomMessage, appName))
+
+		Eventually(logs, cli.Config().DefaultTimeout+3*time.Minute).Should(Say(randomMessage))
+	})
+
+	It("unbinds apps that are deleted from the space", func() {
+		appName := PushLogWriter()
+		apps = append(apps, appName)
+
+		Eventually(func() bool {
+			return HasDrain(appName, drainName)
+		}, cli.Config().DrainReconcileTimeout, 5*time.Second).Should(BeTrue())
+
+		CF("delete", appName, "-f", "-r")
+
+		Eventually(func() bool {
+			return HasDrain(appName, drainName)
+		}, cli.Config().DrainReconcileTimeout, 5*time.Second).Should(BeFalse())
+	})
+
+	It("keeps draining apps that are renamed", func() {
+		appName := PushLogWriter()
+		renamedApp := appName + "-RENAMED"
+		apps = append(apps, renamedApp)
+
+		Eventually(func() bool {
+			return HasDrain(appName, drainName)
+		}, cli.Config().DrainReconcileTimeout, 5*time.Second).Should(BeTrue())
+
+		CF("rename", appName, renamedApp)
+
+		Eventually(func() bool {
+			return HasDrain(renamedApp, drainName)
+		}, cli.Config().DrainReconcileTimeout, 5*time.Second).Should(BeTrue())
+
+		randomMessage := generator.PrefixedRandomName("RANDOM-MESSAGE-B", "LOG")
+
+		logs = LogsFollow(listenerAppName)
+
+		// Renaming an app does not change its route, so requests still go to
+		// the original hostname.
+		writers = append(writers, StartLogWriter(ctx, randomMessage, appName))
+
+		Eventually(logs, cli.Config().DefaultTimeout+3*time.Minute).Should(Say(randomMessage))
+		Consistently(func() bool {
+			return HasDrain(renamedApp, drainName)
+		}, cli.Config().DefaultTimeout, 5*time.Second).Should(BeTrue())
+	})
+})
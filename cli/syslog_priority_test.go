@@ -0,0 +1,96 @@
+package cli_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SyslogPriority", func() {
+
+	const (
+		// facilityUser is the syslog facility for app logs.
+		facilityUser = 1
+
+		severityError = 3
+		severityInfo  = 6
+	)
+
+	var (
+		appName   string
+		drainName string
+		appLogs   *LineStream
+		drainLogs *LineStream
+	)
+
+	BeforeEach(func() {
+		appName = ""
+		drainName = ""
+		appLogs = nil
+		drainLogs = nil
+	})
+
+	AfterEach(func() {
+		if appLogs != nil {
+			appLogs.Kill()
+		}
+		if drainLogs != nil {
+			drainLogs.Kill()
+		}
+
+		if drainName != "" {
+			cf.Cf("delete-drain", drainName, "--force").Wait(cli.Config().DefaultTimeout)
+		}
+
+		if appName != "" {
+			cf.Cf("delete", appName, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		}
+	})
+
+	DescribeTable("maps the app's output stream to a syslog priority",
+		func(stream, label string, severity int) {
+			drainLogs = LogsFollowLines(listenerAppName)
+
+			appName = PushConstantLogger(map[string]string{"LOG_STREAM": stream})
+			appLogs = LogsFollowLines(appName)
+
+			syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
+			drainName = generator.PrefixedRandomName("SYSLOG-PRIORITY", "DRAIN")
+			CF("drain", appName, syslogDrainURL, "--drain-name", drainName)
+
+			timeout := cli.Config().DefaultTimeout + 3*time.Minute
+
+			_, err := appLogs.WaitFor(
+				Matching(fmt.Sprintf(`\[APP/PROC/WEB/0\]\s+%s APP_LOG: %s `, label, appName)),
+				timeout,
+			)
+			Expect(err).ToNot(HaveOccurred(), "cf logs")
+
+			line, err := drainLogs.WaitFor(Containing("APP_LOG: "+appName+" "), timeout)
+			Expect(err).ToNot(HaveOccurred(), "drain")
+
+			m, ok := ParseSyslog(line)
+			Expect(ok).To(BeTrue(), "unparseable drained message: %s", line)
+			Expect(m.Priority).To(Equal(facilityUser*8 + severity))
+			Expect(m.Facility()).To(Equal(facilityUser))
+			Expect(m.Severity()).To(Equal(severity))
+
+			for _, line := range drainLogs.Recent() {
+				if strings.Contains(line, appName) {
+					Expect(line).ToNot(ContainSubstring("INVALID PRIORITY:"))
+				}
+			}
+		},
+		Entry("stdout is info (PRI 14) and labelled OUT", "stdout", "OUT", severityInfo),
+		Entry("stderr is error (PRI 11) and labelled ERR", "stderr", "ERR", severityError),
+	)
+})
@@ -0,0 +1,102 @@
+package cli_test
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/cli"
+
+	. "github.com/cloudfoundry/cfar-logging-acceptance-tests/cli/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TaskAndSidecarLogs", func() {
+
+	const (
+		taskName    = "logging-task"
+		sidecarName = "logging-sidecar"
+		taskLines   = 20
+	)
+
+	var (
+		appName   string
+		drainName string
+		manifest  string
+		appLogs   *LineStream
+		drainLogs *LineStream
+		logStream *LineStream
+	)
+
+	// expectEverywhere waits for a line from the given source type that
+	// matches pattern in cf logs, at the drain and in log-stream.
+	expectEverywhere := func(sourceType, pattern string) {
+		payload := regexp.MustCompile(pattern)
+		timeout := cli.Config().DefaultTimeout + 3*time.Minute
+
+		_, err := appLogs.WaitFor(Matching(fmt.Sprintf(`(?i)\[%s/0\]\s+(OUT|ERR) .*%s`, sourceType, pattern)), timeout)
+		Expect(err).ToNot(HaveOccurred(), "cf logs")
+
+		_, err = drainLogs.WaitFor(Matching(fmt.Sprintf(`(?i)\[%s/0\] - .*%s`, sourceType, pattern)), timeout)
+		Expect(err).ToNot(HaveOccurred(), "drain")
+
+		_, err = logStream.WaitFor(func(line string) bool {
+			e, ok := ParseEnvelope(line)
+			return ok && e.Log != nil &&
+				strings.EqualFold(e.Tags["source_type"], sourceType) &&
+				e.InstanceID == "0" &&
+				payload.Match(e.Log.Payload)
+		}, timeout)
+		Expect(err).ToNot(HaveOccurred(), "log-stream")
+	}
+
+	BeforeEach(func() {
+		// cf push only takes an app name with -f if the manifest has it.
+		appName = generator.PrefixedRandomName("CONSTANT-LOGGER", "")
+		manifest = ConstantLoggerSidecarManifest(appName, sidecarName)
+		PushConstantLoggerNamed(appName, nil, "-f", manifest)
+
+		syslogDrainURL := fmt.Sprintf("https://%s.%s", listenerAppName, cli.Config().CFDomain)
+		drainName = generator.PrefixedRandomName("TASK-SIDECAR", "DRAIN")
+		CF("drain", appName, syslogDrainURL, "--drain-name", drainName)
+
+		appLogs = LogsFollowLines(appName)
+		drainLogs = LogsFollowLines(listenerAppName)
+		logStream = LogStreamLines(appName)
+	})
+
+	AfterEach(func() {
+		appLogs.Kill()
+		drainLogs.Kill()
+		logStream.Kill()
+
+		cf.Cf("delete-drain", drainName, "--force").Wait(cli.Config().DefaultTimeout)
+		cf.Cf("delete", appName, "-f", "-r").Wait(cli.Config().DefaultTimeout)
+		os.Remove(manifest)
+	})
+
+	It("delivers task logs, including the last line before the task exits", func() {
+		CF(
+			"run-task",
+			appName,
+			"--command", fmt.Sprintf("LOG_LABEL=TASK_LOG EXIT_AFTER=%d PORT= constant-logger", taskLines),
+			"--name", taskName,
+		)
+
+		sourceType := "APP/TASK/" + taskName
+
+		expectEverywhere(sourceType, fmt.Sprintf(`TASK_LOG: %s instance=\S* seq=0\b`, appName))
+		expectEverywhere(sourceType, fmt.Sprintf(`EXITING: %s instance=\S* seq=%d\b`, appName, taskLines))
+	})
+
+	It("delivers sidecar logs", func() {
+		sourceType := "APP/PROC/WEB/SIDECAR/" + sidecarName
+
+		expectEverywhere(sourceType, regexp.QuoteMeta("SIDECAR_LOG: "+appName))
+	})
+})
@@ -0,0 +1,173 @@
+// Package logcache reads from the Log Cache HTTP API. It takes its settings
+// from its caller, so that suites with different config can share it.
+package logcache
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readLimit is the most envelopes Log Cache returns per read.
+const readLimit = 1000
+
+// Meta describes what Log Cache holds for one source ID.
+type Meta struct {
+	Count           string `json:"count"`
+	Expired         string `json:"expired"`
+	OldestTimestamp string `json:"oldestTimestamp"`
+	NewestTimestamp string `json:"newestTimestamp"`
+}
+
+// Client reads from the Log Cache of one CF deployment as the user logged
+// in to cf.
+type Client struct {
+	host   string
+	client *http.Client
+}
+
+// NewClient returns a client for the Log Cache at log-cache.<domain>.
+func NewClient(domain string, skipSSLValidation bool, timeout time.Duration) *Client {
+	return &Client{
+		host: "log-cache." + domain,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: skipSSLValidation},
+			},
+		},
+	}
+}
+
+// Read issues a single read for a source ID. The query takes the Log Cache
+// parameters, such as start_time, end_time, envelope_types and limit.
+func (c *Client) Read(sourceID string, query url.Values) ([]Envelope, error) {
+	var resp struct {
+		Envelopes struct {
+			Batch []Envelope `json:"batch"`
+		} `json:"envelopes"`
+	}
+	if err := c.get("/api/v1/read/"+sourceID, query, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Envelopes.Batch, nil
+}
+
+// ReadAll pages through every envelope Log Cache holds for a source ID,
+// oldest first. The query must not set start_time or limit.
+func (c *Client) ReadAll(sourceID string, query url.Values) ([]Envelope, error) {
+	var (
+		all   []Envelope
+		start int64
+	)
+
+	for {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		q.Set("start_time", strconv.FormatInt(start, 10))
+		q.Set("limit", strconv.Itoa(readLimit))
+
+		// Each page starts at the last timestamp of the previous one, so
+		// envelopes sharing it are not skipped. Those already read come
+		// back and are dropped.
+		read := map[string]bool{}
+		for i := len(all) - 1; i >= 0 && all[i].Time() == start; i-- {
+			read[all[i].key()] = true
+		}
+
+		page, err := c.Read(sourceID, q)
+		if err != nil {
+			return nil, err
+		}
+
+		var fresh []Envelope
+		for _, e := range page {
+			if !read[e.key()] {
+				fresh = append(fresh, e)
+			}
+		}
+		if len(fresh) == 0 {
+			return all, nil
+		}
+
+		all = append(all, fresh...)
+		start = fresh[len(fresh)-1].Time()
+	}
+}
+
+// Meta returns the metadata for every source ID visible to the user.
+func (c *Client) Meta() (map[string]Meta, error) {
+	var resp struct {
+		Meta map[string]Meta `json:"meta"`
+	}
+	if err := c.get("/api/v1/meta", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Meta, nil
+}
+
+// Do issues a GET of path with the given token, such as another user's, and
+// returns the status code and body of the response whatever they are.
+func (c *Client) Do(token, path string, query url.Values) (int, []byte, error) {
+	u := url.URL{
+		Scheme:   "https",
+		Host:     c.host,
+		Path:     path,
+		RawQuery: query.Encode(),
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Authorization", token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+func (c *Client) get(path string, query url.Values, v interface{}) error {
+	token, err := OAuthToken()
+	if err != nil {
+		return err
+	}
+
+	status, body, err := c.Do(token, path, query)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d: %s", path, status, body)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// OAuthToken returns the token of the user logged in to cf, including the
+// "bearer" prefix. The command is run directly so the token is never
+// written to the GinkgoWriter.
+func OAuthToken() (string, error) {
+	out, err := exec.Command("cf", "oauth-token").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get oauth token: %s", err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
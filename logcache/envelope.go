@@ -0,0 +1,76 @@
+package logcache
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Envelope is the JSON form of a loggregator v2 envelope as returned by the
+// Log Cache HTTP API and printed by `cf log-stream`. Only the fields the
+// specs use are decoded.
+type Envelope struct {
+	Timestamp  string            `json:"timestamp"`
+	SourceID   string            `json:"source_id"`
+	InstanceID string            `json:"instance_id"`
+	Tags       map[string]string `json:"tags"`
+
+	Log *struct {
+		Payload []byte `json:"payload"`
+		Type    string `json:"type"`
+	} `json:"log"`
+
+	Counter *struct {
+		Name  string `json:"name"`
+		Delta string `json:"delta"`
+		Total string `json:"total"`
+	} `json:"counter"`
+
+	Gauge *struct {
+		Metrics map[string]struct {
+			Unit  string  `json:"unit"`
+			Value float64 `json:"value"`
+		} `json:"metrics"`
+	} `json:"gauge"`
+
+	Timer *struct {
+		Name  string `json:"name"`
+		Start string `json:"start"`
+		Stop  string `json:"stop"`
+	} `json:"timer"`
+
+	Event *struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	} `json:"event"`
+}
+
+// Type returns the envelope type as `cf log-stream --type` names it: log,
+// counter, gauge, timer or event.
+func (e Envelope) Type() string {
+	switch {
+	case e.Log != nil:
+		return "log"
+	case e.Counter != nil:
+		return "counter"
+	case e.Gauge != nil:
+		return "gauge"
+	case e.Timer != nil:
+		return "timer"
+	case e.Event != nil:
+		return "event"
+	}
+	return ""
+}
+
+// Time returns the envelope timestamp in nanoseconds since the epoch, or 0
+// if it has none.
+func (e Envelope) Time() int64 {
+	t, _ := strconv.ParseInt(e.Timestamp, 10, 64)
+	return t
+}
+
+// key identifies an envelope among those sharing its timestamp.
+func (e Envelope) key() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
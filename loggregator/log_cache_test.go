@@ -0,0 +1,281 @@
+package loggregator
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/go-envstruct"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+	"github.com/cloudfoundry/cfar-logging-acceptance-tests/logcache"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var seqRegex = regexp.MustCompile(`seq=(\d+)`)
+
+var _ = Describe("Log Cache", func() {
+	const (
+		// logCount is more than the 1000 envelopes a single read or
+		// `cf logs --recent` returns.
+		logCount = 2500
+	)
+
+	var (
+		cfg        config
+		client     *logcache.Client
+		org, space string
+		appName    string
+		appGUID    string
+	)
+
+	// Every spec reads the same logs, so the app is only pushed for the
+	// first one. The other specs in the suite target orgs of their own, so
+	// the app's space is targeted again for each spec.
+	BeforeEach(func() {
+		if appName != "" {
+			Eventually(cf.Cf("target", "-o", org, "-s", space), defaultTimeout).Should(Exit(0))
+			return
+		}
+
+		err := envstruct.Load(&cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		login(cfg)
+		client = logcache.NewClient(cfg.CFDomain, cfg.SkipSSLValidation, defaultTimeout)
+
+		plugins := cf.Cf("plugins").Wait(defaultTimeout)
+		Expect(plugins.Out.Contents()).To(ContainSubstring("tail"), "log-cache-cli plugin must be installed")
+
+		org = createOrg()
+		logCacheOrg = org
+		space = createSpace()
+
+		appName = deployLogAppWithEnv("log-cache", map[string]string{
+			"LOG_COUNT": strconv.Itoa(logCount),
+		})
+		appGUID = getAppGUID(appName)
+
+		Eventually(func() []uint64 {
+			return sequenceNumbers(appName, payloads(appLogs(readAll(client, appGUID, url.Values{
+				"envelope_types": {"LOG"},
+			}))))
+		}, 3*defaultTimeout, 5*time.Second).Should(HaveLen(logCount), "Log Cache never received every line")
+	})
+
+	Describe("cf tail", func() {
+		It("returns the requested number of lines", func() {
+			lines := cfTail(appName, "--lines", "10", "--envelope-type", "log")
+
+			Expect(sequenceNumbers(appName, lines)).To(Equal(sequence(logCount-10, logCount)))
+		})
+
+		It("returns lines between the start and end time", func() {
+			envelopes := appLogs(readAll(client, appGUID, url.Values{
+				"envelope_types": {"LOG"},
+			}))
+			start := envelopes[100].Time()
+			end := envelopes[199].Time() + 1
+
+			lines := cfTail(
+				appName,
+				"--start-time", strconv.FormatInt(start, 10),
+				"--end-time", strconv.FormatInt(end, 10),
+				"--lines", "1000",
+				"--envelope-type", "log",
+			)
+
+			Expect(sequenceNumbers(appName, lines)).To(Equal(sequence(100, 200)))
+		})
+
+		It("filters by envelope type", func() {
+			// From its first line on, the app's source ID mixes its logs
+			// with its container metrics, so each filter has something to
+			// exclude.
+			start := appLogs(readAll(client, appGUID, url.Values{
+				"envelope_types": {"LOG"},
+			}))[0].Time()
+			tail := func(args ...string) []string {
+				return cfTail(appName, append([]string{
+					"--start-time", strconv.FormatInt(start, 10),
+					"--lines", "1000",
+				}, args...)...)
+			}
+			gauges := func(lines []string) int {
+				n := 0
+				for _, line := range lines {
+					if strings.Contains(line, " GAUGE ") {
+						n++
+					}
+				}
+				return n
+			}
+
+			var all []string
+			Eventually(func() int {
+				all = tail()
+				return gauges(all)
+			}, defaultTimeout, 5*time.Second).ShouldNot(BeZero(), "no gauges next to the app's logs")
+			Expect(sequenceNumbers(appName, all)).ToNot(BeEmpty(), "no logs next to the app's gauges")
+
+			gaugeLines := tail("--envelope-type", "gauge")
+			Expect(gauges(gaugeLines)).ToNot(BeZero())
+			Expect(sequenceNumbers(appName, gaugeLines)).To(BeEmpty(), "logs in the gauge tail")
+
+			logLines := tail("--envelope-type", "log")
+			Expect(sequenceNumbers(appName, logLines)).ToNot(BeEmpty())
+			Expect(gauges(logLines)).To(BeZero(), "gauges in the log tail")
+		})
+	})
+
+	Describe("HTTP API", func() {
+		It("pages through more envelopes than a single read returns", func() {
+			first, err := client.Read(appGUID, url.Values{
+				"envelope_types": {"LOG"},
+				"limit":          {"1000"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(first)).To(BeNumerically("<=", 1000))
+
+			all := appLogs(readAll(client, appGUID, url.Values{
+				"envelope_types": {"LOG"},
+			}))
+
+			Expect(sequenceNumbers(appName, payloads(all))).To(Equal(sequence(0, logCount)))
+		})
+
+		It("only returns envelopes of the requested type", func() {
+			// Every read ends at the same time, a little in the past, so
+			// they are compared over the same envelopes while the app's
+			// container metrics keep arriving.
+			end := strconv.FormatInt(time.Now().Add(-30*time.Second).UnixNano(), 10)
+
+			all := readAll(client, appGUID, url.Values{"end_time": {end}})
+			Expect(ofType(all, "log")).ToNot(BeEmpty())
+			Expect(ofType(all, "gauge")).ToNot(BeEmpty())
+
+			for t, filter := range map[string]string{"log": "LOG", "gauge": "GAUGE"} {
+				filtered := readAll(client, appGUID, url.Values{
+					"envelope_types": {filter},
+					"end_time":       {end},
+				})
+				Expect(filtered).To(Equal(ofType(all, t)), "envelope_types=%s", filter)
+			}
+		})
+
+		It("reports metadata for the app", func() {
+			meta, err := client.Meta()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta).To(HaveKey(appGUID))
+
+			count, err := strconv.Atoi(meta[appGUID].Count)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeNumerically(">=", logCount))
+
+			oldest, err := strconv.ParseInt(meta[appGUID].OldestTimestamp, 10, 64)
+			Expect(err).ToNot(HaveOccurred())
+			newest, err := strconv.ParseInt(meta[appGUID].NewestTimestamp, 10, 64)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(oldest).To(BeNumerically("<=", newest))
+		})
+	})
+})
+
+// logCacheOrg holds the Log Cache app. It is deleted, with the app, after
+// the suite.
+var logCacheOrg string
+
+var _ = AfterSuite(func() {
+	if logCacheOrg != "" {
+		teardownOrg(logCacheOrg)
+	}
+})
+
+func cfTail(appName string, args ...string) []string {
+	session := cf.Cf(append([]string{"tail", appName}, args...)...).Wait(defaultTimeout)
+	Expect(session).To(Exit(0), "Failed to tail "+appName)
+
+	return strings.Split(string(session.Out.Contents()), "\n")
+}
+
+// appLogs returns the log envelopes written by the constant-logger,
+// dropping staging, API and CELL logs.
+func appLogs(envelopes []logcache.Envelope) []logcache.Envelope {
+	var logs []logcache.Envelope
+	for _, e := range envelopes {
+		if e.Log != nil && strings.Contains(string(e.Log.Payload), "APP_LOG: ") {
+			logs = append(logs, e)
+		}
+	}
+	return logs
+}
+
+// payloads returns the payloads of log envelopes.
+func payloads(envelopes []logcache.Envelope) []string {
+	var lines []string
+	for _, e := range envelopes {
+		lines = append(lines, string(e.Log.Payload))
+	}
+	return lines
+}
+
+// sequenceNumbers returns the sequence numbers of appName's APP_LOG lines in
+// the order they appear.
+func sequenceNumbers(appName string, lines []string) []uint64 {
+	var seqs []uint64
+	for _, line := range lines {
+		if !strings.Contains(line, "APP_LOG: "+appName+" ") {
+			continue
+		}
+
+		m := seqRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(m[1], 10, 64)
+		Expect(err).ToNot(HaveOccurred())
+		seqs = append(seqs, seq)
+	}
+
+	return seqs
+}
+
+// sequence returns the numbers in [start, end).
+func sequence(start, end uint64) []uint64 {
+	var seqs []uint64
+	for i := start; i < end; i++ {
+		seqs = append(seqs, i)
+	}
+	return seqs
+}
+
+func getAppGUID(appName string) string {
+	session := cf.Cf("app", appName, "--guid").Wait(defaultTimeout)
+	Expect(session).To(Exit(0), "Failed to get GUID of "+appName)
+
+	return strings.TrimSpace(string(session.Out.Contents()))
+}
+
+// readAll is logcache.Client.ReadAll, failing the spec on errors.
+func readAll(client *logcache.Client, sourceID string, query url.Values) []logcache.Envelope {
+	envelopes, err := client.ReadAll(sourceID, query)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	return envelopes
+}
+
+// ofType returns the envelopes of one type, as Envelope.Type names it.
+func ofType(envelopes []logcache.Envelope, t string) []logcache.Envelope {
+	var matched []logcache.Envelope
+	for _, e := range envelopes {
+		if e.Type() == t {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
@@ -120,6 +120,10 @@ func createSpace() string {
 }
 
 func deployLogApp(name string) string {
+	return deployLogAppWithEnv(name, nil)
+}
+
+func deployLogAppWithEnv(name string, env map[string]string) string {
 	appName := randomName(name)
 	session := cf.Cf(
 		"push",
@@ -141,6 +145,10 @@ func deployLogApp(name string) string {
 
 	Eventually(func() *Session {return session}, defaultTimeout).Should(Exit(0), "Failed to push "+appName)
 
+	for k, v := range env {
+		Eventually(cf.Cf("set-env", appName, k, v), defaultTimeout).Should(Exit(0), "Failed to set "+k)
+	}
+
 	Expect(cf.Cf("start", appName).Wait(defaultTimeout * 3)).Should(Exit(0))
 
 	return appName